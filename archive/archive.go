@@ -0,0 +1,177 @@
+// Package archive records the pages downloaded by a run to a directory and
+// replays them later without network access, so that the parser and screenshot
+// detection can be regression-tested against real, archived data.
+//
+// Each response body is stored at a path derived from its URL: the host, followed
+// by the path of the URL and the ".html" extension (eg. the page
+// https://nightly.changelog.com/2018/02/08 is stored at
+// nightly.changelog.com/2018/02/08.html). Raw files requested from the Github API,
+// like readmes, get the ".md" extension instead, and JSON responses the ".json"
+// extension (eg. api.github.com/repos/user1/repo1/readme.md). Responses to
+// requests with a body, like GraphQL queries, are stored per body, with a hash
+// of the body appended to the path (eg. api.github.com/graphql_1a2b3c4d5e6f7a8b.json).
+package archive
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Path returns the file the body of a page downloaded from u is stored in,
+// inside dir.
+func Path(dir string, u *url.URL) string {
+	p := path.Clean("/" + u.Path)
+	if p == "/" || strings.HasSuffix(u.Path, "/") {
+		p = strings.TrimSuffix(p, "/") + "/index"
+	}
+	if u.RawQuery != "" {
+		p += "_" + url.QueryEscape(u.RawQuery)
+	}
+	return filepath.Join(dir, u.Host, filepath.FromSlash(p)+".html")
+}
+
+// extension() returns the extension of the file the body of the response to r
+// is stored in, depending on the format requested.
+func extension(r *http.Request) string {
+	accept := r.Header.Get("Accept")
+	switch {
+	case strings.HasSuffix(accept, ".raw"):
+		return ".md"
+	case strings.Contains(accept, "json"), strings.HasPrefix(r.Header.Get("Content-Type"), "application/json"):
+		return ".json"
+	}
+	return ".html"
+}
+
+// contentType() returns the content type of a response stored at name.
+func contentType(name string) string {
+	switch filepath.Ext(name) {
+	case ".md":
+		return "text/markdown; charset=utf-8"
+	case ".json":
+		return "application/json; charset=utf-8"
+	}
+	return "text/html; charset=utf-8"
+}
+
+// requestPath() returns the file the body of the response to r is stored in,
+// inside dir. The body of r, if any, is read and replaced, so that r can still
+// be sent.
+func requestPath(dir string, r *http.Request) (string, error) {
+	name := strings.TrimSuffix(Path(dir, r.URL), ".html")
+	ext := extension(r)
+	if r.Body == nil || r.Body == http.NoBody {
+		return name + ext, nil
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if len(body) == 0 {
+		return name + ext, nil
+	}
+
+	hash := sha256.Sum256(body)
+	return name + "_" + hex.EncodeToString(hash[:8]) + ext, nil
+}
+
+// Recorder is an http.RoundTripper that stores the body of every successful
+// response in Dir, passing the response on unchanged.
+type Recorder struct {
+	Dir string
+	// Next sends the requests. http.DefaultTransport is used if nil.
+	Next http.RoundTripper
+}
+
+// NewRecorder returns a transport recording the responses of next to dir.
+func NewRecorder(dir string, next http.RoundTripper) *Recorder {
+	return &Recorder{Dir: dir, Next: next}
+}
+
+// RoundTrip sends the request and records the body of the response, if the
+// response status is 200.
+func (rec *Recorder) RoundTrip(r *http.Request) (*http.Response, error) {
+	next := rec.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	name, err := requestPath(rec.Dir, r)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := next.RoundTrip(r)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	err = os.MkdirAll(filepath.Dir(name), 0755)
+	if err != nil {
+		return nil, err
+	}
+	err = ioutil.WriteFile(name, body, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Replayer is an http.RoundTripper serving requests from the files in Dir,
+// as stored by Recorder, without any network access. Requests for files that
+// were not recorded get a 404 Not Found response.
+type Replayer struct {
+	Dir string
+}
+
+// NewReplayer returns a transport replaying the responses recorded to dir.
+func NewReplayer(dir string) *Replayer {
+	return &Replayer{Dir: dir}
+}
+
+// RoundTrip replies with the recorded body for the URL and body of the request.
+func (rep *Replayer) RoundTrip(r *http.Request) (*http.Response, error) {
+	name, err := requestPath(rep.Dir, r)
+	if err != nil {
+		return nil, err
+	}
+
+	status := http.StatusOK
+	body, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		status = http.StatusNotFound
+		body = []byte{}
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:        strconv.Itoa(status) + " " + http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {contentType(name)}},
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       r,
+	}, nil
+}
@@ -0,0 +1,185 @@
+package archive
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"Nightly page", "https://nightly.changelog.com/2018/02/08", "nightly.changelog.com/2018/02/08.html"},
+		{"Trailing slash", "https://nightly.changelog.com/2018/02/08/", "nightly.changelog.com/2018/02/08/index.html"},
+		{"Root", "https://nightly.changelog.com", "nightly.changelog.com/index.html"},
+		{"Nested path", "https://example.com/a/b/c", "example.com/a/b/c.html"},
+		{"Query", "http://localhost:8080/page?day=2018-02-08", "localhost:8080/page_day%3D2018-02-08.html"},
+		{"Parent directory", "https://example.com/../../etc/passwd", "example.com/etc/passwd.html"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := Path("dir", u); got != filepath.Join("dir", filepath.FromSlash(tt.want)) {
+				t.Errorf("Path() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecorder_Replayer(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("page " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	recording := &http.Client{Transport: NewRecorder(dir, server.Client().Transport)}
+	for _, p := range []string{"/2018/02/08", "/missing"} {
+		resp, err := recording.Get(server.URL + p)
+		if err != nil {
+			t.Fatalf("GET %s failed with error: %v", p, err)
+		}
+		resp.Body.Close()
+	}
+	server.Close()
+
+	replaying := &http.Client{Transport: NewReplayer(dir)}
+	resp, err := replaying.Get(server.URL + "/2018/02/08")
+	if err != nil {
+		t.Fatalf("Replaying failed with error: %v", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "page /2018/02/08" {
+		t.Errorf("Replayed %d %q, want the recorded page", resp.StatusCode, body)
+	}
+
+	resp, err = replaying.Get(server.URL + "/missing")
+	if err != nil {
+		t.Fatalf("Replaying failed with error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Replayed status %d for a page that was not recorded, want 404", resp.StatusCode)
+	}
+}
+
+func TestRecorder_Replayer_Post(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write([]byte("reply to " + string(body)))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	recording := &http.Client{Transport: NewRecorder(dir, server.Client().Transport)}
+	for _, query := range []string{"query A", "query B"} {
+		resp, err := recording.Post(server.URL+"/graphql", "application/json", strings.NewReader(query))
+		if err != nil {
+			t.Fatalf("POST %q failed with error: %v", query, err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "reply to "+query {
+			t.Errorf("Recorder sent %q, want the request body passed on", body)
+		}
+	}
+	server.Close()
+
+	replaying := &http.Client{Transport: NewReplayer(dir)}
+	tests := []struct {
+		query  string
+		status int
+		want   string
+	}{
+		{"query A", http.StatusOK, "reply to query A"},
+		{"query B", http.StatusOK, "reply to query B"},
+		{"query C", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		resp, err := replaying.Post(server.URL+"/graphql", "application/json", strings.NewReader(tt.query))
+		if err != nil {
+			t.Fatalf("Replaying failed with error: %v", err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.status || string(body) != tt.want {
+			t.Errorf("Replayed %d %q for %q, want %d %q", resp.StatusCode, body, tt.query, tt.status, tt.want)
+		}
+	}
+}
+
+func TestRecorder_Replayer_Extension(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("body"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		body        string
+		want        string
+		wantType    string
+	}{
+		{"Page", "", "", "", "page.html", "text/html; charset=utf-8"},
+		{"Raw readme", "application/vnd.github.v3.raw", "", "", "page.md", "text/markdown; charset=utf-8"},
+		{"JSON", "application/vnd.github.mercy-preview+json", "", "", "page.json", "application/json; charset=utf-8"},
+		{"GraphQL", "", "application/json; charset=utf-8", "{}", "page_44136fa355b3678a.json", "application/json; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, transport := range []http.RoundTripper{NewRecorder(dir, server.Client().Transport), NewReplayer(dir)} {
+				method := "GET"
+				if tt.body != "" {
+					method = "POST"
+				}
+				r, _ := http.NewRequest(method, server.URL+"/page", strings.NewReader(tt.body))
+				if tt.body == "" {
+					r.Body = http.NoBody
+				}
+				r.Header.Set("Accept", tt.accept)
+				r.Header.Set("Content-Type", tt.contentType)
+				resp, err := transport.RoundTrip(r)
+				if err != nil {
+					t.Fatalf("RoundTrip() failed with error: %v", err)
+				}
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					t.Fatalf("RoundTrip() status = %d, want 200", resp.StatusCode)
+				}
+			}
+
+			u, _ := url.Parse(server.URL)
+			name := filepath.Join(dir, u.Host, tt.want)
+			if _, err := ioutil.ReadFile(name); err != nil {
+				t.Errorf("Response was not recorded to %s: %v", tt.want, err)
+			}
+			if got := contentType(name); got != tt.wantType {
+				t.Errorf("contentType() = %v, want %v", got, tt.wantType)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+// Command changelog-nightly-parser is an AWS Lambda function that visits the Changelog Nightly
+// page, extracts the URLs of trending repositories found and stores them as a JSON file
+// the the specified Github repository.
+//
+// Three environment variables have to be defined for the Lambda function to work:
+// - GITHUB_REPOSITORY - name of repository to which to upload the JSON file (eg. "trending-daily").
+// - GITHUB_OWNER - Github username (eg. "myusername")
+// - GITHUB_TOKEN - Github personal token (eg. "myusername")
+//
+// Instead of GITHUB_TOKEN, the function can authenticate as a Github App installation,
+// if GITHUB_APP_ID, GITHUB_APP_INSTALLATION_ID and GITHUB_APP_PRIVATE_KEY are defined.
+//
+// Optionally, GITHUB_UPLOAD_API can be set to "contents" to upload files one by one
+// with the Contents API, instead of in a single commit with the Git Data API.
+// The branch, commit identities, message and path of the daily file can be
+// configured too, see pipeline.LoadConfig() for details.
+//
+// Repositories can be filtered before publishing with the rules in NIGHTLY_FILTER_FILE,
+// or in the Filter field of the invocation event, see filter.Rules.
+//
+// The day processed is yesterday in the NIGHTLY_TIME_ZONE time zone (UTC by default),
+// or the day before if NIGHTLY_CUTOFF_HOUR has not passed yet or yesterday's page
+// is not published yet.
+//
+// Run as "changelog-nightly-parser replay" to process pages archived with
+// NIGHTLY_RECORD_DIR offline, see replay() for details, or as
+// "changelog-nightly-parser stats" to print how repositories trended over the
+// published days, see stats().
+//
+// Logs are structured: JSON lines in Lambda and key=value text on the command
+// line, unless LOG_FORMAT says otherwise. LOG_LEVEL sets the verbosity.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"os"
+	_ "time/tzdata" // NIGHTLY_TIME_ZONE must work even if the runtime has no zoneinfo
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/quasoft/changelog-nightly-parser/filter"
+	"github.com/quasoft/changelog-nightly-parser/pipeline"
+)
+
+// Event is the input of the Lambda function. All fields are optional.
+type Event struct {
+	// Filter replaces the filter rules of NIGHTLY_FILTER_FILE for the invocation.
+	// It is parsed with filter.Parse, so unknown fields are rejected like in the file.
+	Filter json.RawMessage `json:"Filter"`
+}
+
+// Handler is a lambda function that visits the Changelog Nightly page, extracts URLs
+// to the trending repositories in all three categories, prepares a JSON file with the
+// URLs and commits that file to a Github repository.
+// Each invocation reads the configuration from the environment variables and runs
+// a new Pipeline. Settings in the event take precedence over the environment.
+// Requests are sent with the context of the invocation, so they are cancelled
+// when the invocation times out.
+func Handler(ctx context.Context, event Event) error {
+	cfg, err := pipeline.LoadConfig(os.Getenv)
+	if err != nil {
+		return err
+	}
+	if len(event.Filter) > 0 && string(event.Filter) != "null" {
+		cfg.Filter, err = filter.Parse(bytes.NewReader(event.Filter))
+		if err != nil {
+			return err
+		}
+	}
+	if cfg.LogFormat == "" {
+		cfg.LogFormat = "json"
+	}
+
+	return pipeline.NewPipeline(cfg).RunContext(ctx)
+}
+
+// cliLogger() returns the logger of the replay and stats commands, writing text
+// (unless LOG_FORMAT is "json") to stderr, with the LOG_LEVEL verbosity.
+func cliLogger(getenv func(string) string) (*slog.Logger, error) {
+	cfg, err := pipeline.LoadSourceConfig(getenv)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.LogFormat == "" {
+		cfg.LogFormat = "text"
+	}
+	return pipeline.NewLogger(os.Stderr, cfg.LogFormat, cfg.LogLevel), nil
+}
+
+func main() {
+	if len(os.Args) > 1 && (os.Args[1] == "replay" || os.Args[1] == "stats") {
+		logger, err := cliLogger(os.Getenv)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if os.Args[1] == "replay" {
+			err = replay(os.Args[2:], os.Getenv, logger)
+		} else {
+			err = stats(os.Args[2:], os.Getenv, os.Stdout, logger)
+		}
+		if err != nil {
+			logger.Error("Command failed", "command", os.Args[1], "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	lambda.Start(Handler)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestHandler_NoEnvVariables(t *testing.T) {
+	// Execute lambda function
+	t.Setenv("GITHUB_OWNER", "")
+	t.Setenv("GITHUB_REPOSITORY", "trending-daily")
+	t.Setenv("GITHUB_TOKEN", "123")
+	err := Handler(context.Background(), Event{})
+	if err == nil {
+		t.Fatalf("Should have returned an error when GITHUB_OWNER environment variable is not set.")
+	}
+
+	t.Setenv("GITHUB_OWNER", "user")
+	t.Setenv("GITHUB_REPOSITORY", "")
+	t.Setenv("GITHUB_TOKEN", "123")
+	err = Handler(context.Background(), Event{})
+	if err == nil {
+		t.Fatalf("Should have returned an error when GITHUB_REPOSITORY environment variable is not set.")
+	}
+
+	t.Setenv("GITHUB_OWNER", "user")
+	t.Setenv("GITHUB_REPOSITORY", "trending-daily")
+	t.Setenv("GITHUB_TOKEN", "")
+	err = Handler(context.Background(), Event{})
+	if err == nil {
+		t.Fatalf("Should have returned an error when GITHUB_TOKEN environment variable is not set.")
+	}
+}
+
+func TestHandler_InvalidFilter(t *testing.T) {
+	t.Setenv("GITHUB_OWNER", "user")
+	t.Setenv("GITHUB_REPOSITORY", "trending-daily")
+	t.Setenv("GITHUB_TOKEN", "123")
+
+	tests := []struct {
+		name   string
+		filter string
+		want   string
+	}{
+		{"Invalid pattern", `{"ExcludeNames": ["(awesome"]}`, "ExcludeNames"},
+		{"Unknown field", `{"ExcludeName": ["awesome"]}`, "unknown field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := Event{}
+			err := json.Unmarshal([]byte(`{"Filter": `+tt.filter+`}`), &event)
+			if err != nil {
+				t.Fatalf("Decoding event failed with error: %v", err)
+			}
+
+			err = Handler(context.Background(), event)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Should have returned an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/archive"
+	"github.com/quasoft/changelog-nightly-parser/pipeline"
+)
+
+// replay() runs the parser and screenshot detection for every day in a range,
+// entirely from the pages and readmes archived in a directory (as recorded with
+// NIGHTLY_RECORD_DIR), and writes the daily JSON files to the output directory.
+// Days without an archived page are skipped. Nothing is uploaded to Github.
+// The metrics and trace spans of all days are exported at the end, if enabled
+// with METRICS and OTEL_TRACES_EXPORTER.
+//
+// Usage: changelog-nightly-parser replay -archive DIR -out DIR -from YYYY-MM-DD [-to YYYY-MM-DD]
+func replay(args []string, getenv func(string) string, logger *slog.Logger) error {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	archiveDir := flags.String("archive", "", "directory with the recorded pages and readmes")
+	outDir := flags.String("out", ".", "directory to write the daily JSON files to")
+	from := flags.String("from", "", "first day to replay (YYYY-MM-DD)")
+	to := flags.String("to", "", "last day to replay (YYYY-MM-DD, default: the first day)")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *archiveDir == "" {
+		return fmt.Errorf("Archive directory not specified")
+	}
+	if *to == "" {
+		*to = *from
+	}
+	first, err := time.Parse("2006-01-02", *from)
+	if err != nil {
+		return fmt.Errorf("Invalid first day %q: %v", *from, err)
+	}
+	last, err := time.Parse("2006-01-02", *to)
+	if err != nil {
+		return fmt.Errorf("Invalid last day %q: %v", *to, err)
+	}
+
+	cfg, err := pipeline.LoadSourceConfig(getenv)
+	if err != nil {
+		return err
+	}
+	p := pipeline.NewPipeline(cfg)
+	p.Downloader = &http.Client{Transport: archive.NewReplayer(*archiveDir)}
+	p.Logger = logger
+
+	err = os.MkdirAll(*outDir, 0755)
+	if err != nil {
+		return err
+	}
+
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		j, err := p.Daily(day)
+		if err == pipeline.ErrPageNotFound {
+			logger.Info("No archived page, skipping", "date", day.Format("2006-01-02"))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		name := filepath.Join(*outDir, day.Format("2006-01-02")+".json")
+		err = ioutil.WriteFile(name, j, 0644)
+		if err != nil {
+			return err
+		}
+		logger.Info("Wrote daily file", "date", day.Format("2006-01-02"), "file", name)
+	}
+	err = p.Metrics.Flush()
+	if err != nil {
+		return err
+	}
+	return p.Tracer.Flush(context.Background())
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/archive"
+	"github.com/quasoft/changelog-nightly-parser/pipeline"
+)
+
+// fixtureTransport serves the nightly page and readme fixtures, standing in for
+// the live sites while recording.
+type fixtureTransport struct{}
+
+func (fixtureTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	name := ""
+	switch {
+	case r.URL.String() == "https://nightly.changelog.com/2018/02/08":
+		name = "../../nightly/testdata/nightly.html"
+	case strings.HasSuffix(r.URL.Path, "/readme"):
+		name = "../../pipeline/testdata/readme.html"
+	}
+
+	status := http.StatusNotFound
+	body := []byte{}
+	if name != "" {
+		var err error
+		body, err = ioutil.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		status = http.StatusOK
+	}
+	return &http.Response{
+		StatusCode: status,
+		Body:       ioutil.NopCloser(bytes.NewReader(body)),
+		Header:     http.Header{},
+		Request:    r,
+	}, nil
+}
+
+func TestReplay(t *testing.T) {
+	archiveDir := t.TempDir()
+	outDir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(ioutil.Discard, nil))
+	getenv := func(string) string { return "" }
+
+	// Record a live run
+	cfg, err := pipeline.LoadSourceConfig(getenv)
+	if err != nil {
+		t.Fatalf("LoadSourceConfig() failed with error: %v", err)
+	}
+	p := pipeline.NewPipeline(cfg)
+	p.Downloader = &http.Client{Transport: archive.NewRecorder(archiveDir, fixtureTransport{})}
+	p.Logger = logger
+	live, err := p.Daily(time.Date(2018, 2, 8, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Daily() failed with error: %v", err)
+	}
+
+	// Readmes are recorded like the fixtures in pipeline/testdata/archive
+	readmes, _ := filepath.Glob(filepath.Join(archiveDir, "api.github.com", "repos", "*", "*", "readme.md"))
+	if len(readmes) == 0 {
+		t.Errorf("No readme.md was recorded to %s", archiveDir)
+	}
+
+	// Replay it offline, together with a day that was not recorded
+	args := []string{"-archive", archiveDir, "-out", outDir, "-from", "2018-02-07", "-to", "2018-02-08"}
+	err = replay(args, getenv, logger)
+	if err != nil {
+		t.Fatalf("replay() failed with error: %v", err)
+	}
+
+	replayed, err := ioutil.ReadFile(filepath.Join(outDir, "2018-02-08.json"))
+	if err != nil {
+		t.Fatalf("Replayed file was not written: %v", err)
+	}
+	if !bytes.Equal(replayed, live) {
+		t.Errorf("Replayed output differs from the live run.\nreplayed: %s\nlive: %s", replayed, live)
+	}
+	if !bytes.Contains(live, []byte("images/screenshot.jpg")) {
+		t.Errorf("Live run did not detect screenshots: %s", live)
+	}
+
+	if _, err := ioutil.ReadFile(filepath.Join(outDir, "2018-02-07.json")); err == nil {
+		t.Errorf("A file was written for a day without an archived page")
+	}
+}
+
+func TestReplay_InvalidArgs(t *testing.T) {
+	getenv := func(string) string { return "" }
+	logger := slog.New(slog.NewTextHandler(ioutil.Discard, nil))
+
+	tests := [][]string{
+		{"-from", "2018-02-08"},
+		{"-archive", "dir", "-from", "08.02.2018"},
+		{"-archive", "dir", "-unknown"},
+	}
+	for _, args := range tests {
+		if err := replay(args, getenv, logger); err == nil {
+			t.Errorf("replay(%v) should have failed", args)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/history"
+	"github.com/quasoft/changelog-nightly-parser/pipeline"
+)
+
+// stats() computes the trend statistics of every repository (see history.Analyze)
+// over the daily files in a local directory, or, if no directory is given, over
+// the daily files uploaded to the Github repository configured with the GITHUB_*
+// environment variables. Writes the statistics as JSON to out.
+//
+// Usage: changelog-nightly-parser stats [-dir DIR] [-from YYYY-MM-DD] [-to YYYY-MM-DD]
+func stats(args []string, getenv func(string) string, out io.Writer, logger *slog.Logger) error {
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	dir := flags.String("dir", "", "directory with the daily JSON files (default: the Github repository)")
+	from := flags.String("from", "", "first day to include (YYYY-MM-DD, default: the oldest day)")
+	to := flags.String("to", "", "last day to include (YYYY-MM-DD, default: the most recent day)")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	var first, last time.Time
+	if *from != "" {
+		first, err = time.Parse("2006-01-02", *from)
+		if err != nil {
+			return fmt.Errorf("Invalid first day %q: %v", *from, err)
+		}
+	}
+	if *to != "" {
+		last, err = time.Parse("2006-01-02", *to)
+		if err != nil {
+			return fmt.Errorf("Invalid last day %q: %v", *to, err)
+		}
+	}
+
+	var days []history.Day
+	if *dir != "" {
+		days, err = history.LoadDir(*dir)
+	} else {
+		var cfg *pipeline.Config
+		cfg, err = pipeline.LoadConfig(getenv)
+		if err != nil {
+			return err
+		}
+		p := pipeline.NewPipeline(cfg)
+		p.Logger = logger
+		days, err = p.History()
+	}
+	if err != nil {
+		return err
+	}
+	days = history.Between(days, first, last)
+	logger.Info("Analyzing days", "days", len(days))
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(history.Analyze(days))
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/quasoft/changelog-nightly-parser/history"
+)
+
+func TestStats(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"2018-02-08.json": `{"FirstTimers": [{"Name": "acme/rocket", "Stars": 100}], "TopNew": [], "RepeatPerformers": []}`,
+		"2018-02-09.json": `{"FirstTimers": [], "TopNew": [], "RepeatPerformers": [{"Name": "acme/rocket", "Stars": 150}]}`,
+		"2018-02-10.json": `{"FirstTimers": [{"Name": "acme/jet", "Stars": 10}], "TopNew": [], "RepeatPerformers": []}`,
+	}
+	for name, content := range files {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := &bytes.Buffer{}
+	err := stats([]string{"-dir", dir, "-to", "2018-02-09"}, func(string) string { return "" }, out, slog.New(slog.NewTextHandler(ioutil.Discard, nil)))
+	if err != nil {
+		t.Fatalf("stats() failed with error: %v", err)
+	}
+
+	s := history.Stats{}
+	err = json.Unmarshal(out.Bytes(), &s)
+	if err != nil {
+		t.Fatalf("stats() wrote invalid JSON: %v\n%s", err, out)
+	}
+	if s.Days != 2 || len(s.Repositories) != 1 || s.Repositories[0].Name != "acme/rocket" || s.Repositories[0].CurrentStreak != 2 {
+		t.Errorf("stats() = %+v", s)
+	}
+}
+
+func TestStats_InvalidArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"Invalid first day", []string{"-dir", ".", "-from", "yesterday"}},
+		{"Invalid last day", []string{"-dir", ".", "-to", "2018-13-01"}},
+		{"No Github settings", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := stats(tt.args, func(string) string { return "" }, ioutil.Discard, slog.New(slog.NewTextHandler(ioutil.Discard, nil)))
+			if err == nil {
+				t.Errorf("stats() should have failed")
+			}
+		})
+	}
+}
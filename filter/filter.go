@@ -0,0 +1,205 @@
+// Package filter selects which trending repositories get published, with
+// declarative rules on the language, stars, name, description and owner of
+// each repository.
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+// Rules are the conditions a repository has to meet to be published.
+// Zero fields don't exclude any repositories. Patterns are regular expressions
+// in Go syntax (eg. "(?i)awesome" for a case-insensitive match).
+type Rules struct {
+	// Languages, if not empty, are the only languages published (case-insensitive).
+	// Repositories without a language are excluded.
+	Languages []string `json:"Languages"`
+	// ExcludeLanguages are languages that are not published (case-insensitive).
+	ExcludeLanguages []string `json:"ExcludeLanguages"`
+	// MinStars is the minimum number of stars.
+	MinStars int `json:"MinStars"`
+	// MinNewStars is the minimum number of stars gained on the day.
+	MinNewStars int `json:"MinNewStars"`
+	// IncludeNames, if not empty, are patterns one of which the name ("owner/repo") has to match.
+	IncludeNames []string `json:"IncludeNames"`
+	// ExcludeNames are patterns none of which the name ("owner/repo") may match.
+	ExcludeNames []string `json:"ExcludeNames"`
+	// IncludeDescriptions, if not empty, are patterns one of which the description has to match.
+	IncludeDescriptions []string `json:"IncludeDescriptions"`
+	// ExcludeDescriptions are patterns none of which the description may match.
+	ExcludeDescriptions []string `json:"ExcludeDescriptions"`
+	// ExcludeOwners are owners whose repositories are not published (case-insensitive).
+	ExcludeOwners []string `json:"ExcludeOwners"`
+
+	includeNames        []*regexp.Regexp
+	excludeNames        []*regexp.Regexp
+	includeDescriptions []*regexp.Regexp
+	excludeDescriptions []*regexp.Regexp
+}
+
+// Rejection is a repository that was filtered out, with the reasons why.
+type Rejection struct {
+	Category string   `json:"Category"`
+	Name     string   `json:"Name"`
+	URL      string   `json:"URL"`
+	Reasons  []string `json:"Reasons"`
+}
+
+// Report lists the repositories that were filtered out.
+type Report struct {
+	Kept     int         `json:"Kept"`
+	Filtered []Rejection `json:"Filtered"`
+}
+
+// Parse reads rules in JSON format and compiles their patterns.
+func Parse(r io.Reader) (*Rules, error) {
+	rules := &Rules{}
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	err := dec.Decode(rules)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid filter rules: %v", err)
+	}
+
+	err = rules.Compile()
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// LoadFile reads rules in JSON format from the file at path.
+func LoadFile(path string) (*Rules, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Parse(f)
+}
+
+// Compile compiles the patterns of the rules. It has to be called before Apply
+// if the rules were not created with Parse or LoadFile.
+func (rules *Rules) Compile() error {
+	var err error
+	rules.includeNames, err = compile("IncludeNames", rules.IncludeNames)
+	if err != nil {
+		return err
+	}
+	rules.excludeNames, err = compile("ExcludeNames", rules.ExcludeNames)
+	if err != nil {
+		return err
+	}
+	rules.includeDescriptions, err = compile("IncludeDescriptions", rules.IncludeDescriptions)
+	if err != nil {
+		return err
+	}
+	rules.excludeDescriptions, err = compile("ExcludeDescriptions", rules.ExcludeDescriptions)
+	return err
+}
+
+// compile() compiles the patterns of a field of the rules.
+func compile(field string, patterns []string) ([]*regexp.Regexp, error) {
+	compiled := []*regexp.Regexp{}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s pattern %q: %v", field, pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// containsFold() reports whether the list contains s, case-insensitively.
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAny() returns the first pattern that matches s, or nil if none does.
+func matchesAny(patterns []*regexp.Regexp, s string) *regexp.Regexp {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return re
+		}
+	}
+	return nil
+}
+
+// Reasons returns the reasons why the repository is filtered out, or nothing
+// if it meets all rules.
+func (rules *Rules) Reasons(r *nightly.Repository) []string {
+	reasons := []string{}
+
+	if len(rules.Languages) > 0 && !containsFold(rules.Languages, r.Language) {
+		reasons = append(reasons, fmt.Sprintf("language %q is not allowed", r.Language))
+	}
+	if containsFold(rules.ExcludeLanguages, r.Language) {
+		reasons = append(reasons, fmt.Sprintf("language %q is excluded", r.Language))
+	}
+	if r.Stars < rules.MinStars {
+		reasons = append(reasons, fmt.Sprintf("%d stars, less than %d", r.Stars, rules.MinStars))
+	}
+	if r.NewStars < rules.MinNewStars {
+		reasons = append(reasons, fmt.Sprintf("%d new stars, less than %d", r.NewStars, rules.MinNewStars))
+	}
+	if len(rules.includeNames) > 0 && matchesAny(rules.includeNames, r.Name) == nil {
+		reasons = append(reasons, "name does not match any of IncludeNames")
+	}
+	if re := matchesAny(rules.excludeNames, r.Name); re != nil {
+		reasons = append(reasons, fmt.Sprintf("name matches %q", re.String()))
+	}
+	if len(rules.includeDescriptions) > 0 && matchesAny(rules.includeDescriptions, r.Description) == nil {
+		reasons = append(reasons, "description does not match any of IncludeDescriptions")
+	}
+	if re := matchesAny(rules.excludeDescriptions, r.Description); re != nil {
+		reasons = append(reasons, fmt.Sprintf("description matches %q", re.String()))
+	}
+	owner := strings.SplitN(r.Name, "/", 2)[0]
+	if containsFold(rules.ExcludeOwners, owner) {
+		reasons = append(reasons, fmt.Sprintf("owner %q is excluded", owner))
+	}
+	return reasons
+}
+
+// Apply removes the repositories that don't meet the rules from all three
+// categories of tr, and returns a report of the repositories removed.
+func (rules *Rules) Apply(tr *nightly.TrendingRepos) *Report {
+	report := &Report{Filtered: []Rejection{}}
+	keep := func(category string, repos []nightly.Repository) []nightly.Repository {
+		kept := []nightly.Repository{}
+		for i := range repos {
+			reasons := rules.Reasons(&repos[i])
+			if len(reasons) == 0 {
+				kept = append(kept, repos[i])
+				continue
+			}
+			report.Filtered = append(report.Filtered, Rejection{
+				Category: category,
+				Name:     repos[i].Name,
+				URL:      repos[i].URL,
+				Reasons:  reasons,
+			})
+		}
+		report.Kept += len(kept)
+		return kept
+	}
+
+	tr.First = keep(nightly.FirstTimers, tr.First)
+	tr.New = keep(nightly.TopNew, tr.New)
+	tr.Repeaters = keep(nightly.RepeatPerformers, tr.Repeaters)
+	return report
+}
@@ -0,0 +1,97 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+func TestRules_Reasons(t *testing.T) {
+	repo := nightly.Repository{
+		Name:        "acme/awesome-rockets",
+		Description: "A curated list of rockets",
+		Language:    "Go",
+		Stars:       100,
+		NewStars:    10,
+	}
+
+	tests := []struct {
+		name  string
+		rules string
+		want  []string
+	}{
+		{"No rules", `{}`, []string{}},
+		{"Allowed language", `{"Languages": ["go", "Rust"]}`, []string{}},
+		{"Language not allowed", `{"Languages": ["Rust"]}`, []string{`language "Go" is not allowed`}},
+		{"Excluded language", `{"ExcludeLanguages": ["GO"]}`, []string{`language "Go" is excluded`}},
+		{"Min stars", `{"MinStars": 101}`, []string{"100 stars, less than 101"}},
+		{"Min new stars", `{"MinNewStars": 11}`, []string{"10 new stars, less than 11"}},
+		{"Excluded name", `{"ExcludeNames": ["(?i)awesome"]}`, []string{`name matches "(?i)awesome"`}},
+		{"Included name", `{"IncludeNames": ["^acme/"]}`, []string{}},
+		{"Name not included", `{"IncludeNames": ["^jane/"]}`, []string{"name does not match any of IncludeNames"}},
+		{"Excluded description", `{"ExcludeDescriptions": ["curated list"]}`, []string{`description matches "curated list"`}},
+		{"Description not included", `{"IncludeDescriptions": ["framework"]}`, []string{"description does not match any of IncludeDescriptions"}},
+		{"Excluded owner", `{"ExcludeOwners": ["ACME"]}`, []string{`owner "acme" is excluded`}},
+		{
+			"Several reasons",
+			`{"MinStars": 1000, "ExcludeOwners": ["acme"]}`,
+			[]string{"100 stars, less than 1000", `owner "acme" is excluded`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := Parse(strings.NewReader(tt.rules))
+			if err != nil {
+				t.Fatalf("Parse() failed with error: %v", err)
+			}
+			got := rules.Reasons(&repo)
+			if strings.Join(got, "; ") != strings.Join(tt.want, "; ") {
+				t.Errorf("Rules.Reasons() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+	}{
+		{"Not JSON", `languages: go`},
+		{"Unknown field", `{"MinimumStars": 10}`},
+		{"Invalid pattern", `{"ExcludeNames": ["(awesome"]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.rules))
+			if err == nil {
+				t.Errorf("Parse() should have failed")
+			}
+		})
+	}
+}
+
+func TestRules_Apply(t *testing.T) {
+	tr := &nightly.TrendingRepos{
+		First:     []nightly.Repository{{Name: "acme/rocket", Language: "Go"}, {Name: "acme/notes", Language: "Rust"}},
+		New:       []nightly.Repository{{Name: "jane/tasks", Language: "Python"}},
+		Repeaters: []nightly.Repository{{Name: "jane/jet", Language: "Go"}},
+	}
+	rules := &Rules{Languages: []string{"Go"}}
+	err := rules.Compile()
+	if err != nil {
+		t.Fatalf("Compile() failed with error: %v", err)
+	}
+
+	report := rules.Apply(tr)
+	if len(tr.First) != 1 || tr.First[0].Name != "acme/rocket" || len(tr.New) != 0 || len(tr.Repeaters) != 1 {
+		t.Errorf("Rules.Apply() kept %+v", tr)
+	}
+	if report.Kept != 2 || len(report.Filtered) != 2 {
+		t.Fatalf("Rules.Apply() report = %+v", report)
+	}
+	if f := report.Filtered[1]; f.Category != "TopNew" || f.Name != "jane/tasks" || len(f.Reasons) != 1 {
+		t.Errorf("Rules.Apply() rejection = %+v", f)
+	}
+}
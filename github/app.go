@@ -0,0 +1,193 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenSource provides the access token used to authorize Github API requests.
+// The context is that of the request being authorized, if any token has to be
+// requested first.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is a TokenSource for a personal access token.
+type StaticToken string
+
+// Token returns the personal access token.
+func (t StaticToken) Token(ctx context.Context) (string, error) {
+	return string(t), nil
+}
+
+// AppTokenSource is a TokenSource that authenticates as a Github App installation.
+// It signs a JWT with the private key of the App, exchanges it for an installation
+// access token and caches that token until shortly before it expires.
+type AppTokenSource struct {
+	AppID          string
+	InstallationID string
+	Key            *rsa.PrivateKey
+
+	// Client and API are used for requesting installation tokens from the Github API.
+	Client Doer
+	API    string
+	Logger *slog.Logger
+	Now    func() time.Time
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// appTokenRefreshMargin is how long before expiry an installation token is refreshed.
+const appTokenRefreshMargin = 5 * time.Minute
+
+// ParsePrivateKey parses an RSA private key in PKCS #1 (as generated by Github)
+// or PKCS #8 PEM format.
+func ParsePrivateKey(privateKeyPEM []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("Github App private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse Github App private key: %v", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("Github App private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// jwt() returns a JSON Web Token signed with the private key of the App, valid
+// for 10 minutes, as required for authenticating as a Github App
+// (https://developer.github.com/apps/building-github-apps/authenticating-with-github-apps/).
+func (s *AppTokenSource) jwt() (string, error) {
+	now := s.Now()
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		// Issued a minute in the past to allow for clock drift
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": s.AppID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.Key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Token returns the cached installation access token, or requests a new one
+// with the given context if there is no token yet or the cached one is about
+// to expire.
+func (s *AppTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && s.Now().Add(appTokenRefreshMargin).Before(s.expires) {
+		return s.token, nil
+	}
+
+	jwt, err := s.jwt()
+	if err != nil {
+		return "", err
+	}
+
+	// POST /app/installations/:installation_id/access_tokens
+	u := fmt.Sprintf("%s/app/installations/%s/access_tokens", s.API, s.InstallationID)
+	r, err := http.NewRequestWithContext(ctx, "POST", u, nil)
+	if err != nil {
+		return "", err
+	}
+	r.Header.Set("Authorization", "Bearer "+jwt)
+	r.Header.Set("Accept", "application/vnd.github.machine-man-preview+json")
+
+	resp, err := s.Client.Do(r)
+	if err != nil {
+		return "", fmt.Errorf("requesting installation token from %s failed with error: %v", u, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		msg, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			msg = []byte{}
+		}
+		return "", fmt.Errorf("requesting installation token from %s failed with status %d, msg: %s", u, resp.StatusCode, string(msg))
+	}
+
+	token := struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&token)
+	if err != nil {
+		return "", err
+	}
+
+	loggerOrDiscard(s.Logger).Info("Obtained installation token for Github App", "app_id", s.AppID, "expires", token.ExpiresAt.Format(time.RFC3339))
+	s.token = token.Token
+	s.expires = token.ExpiresAt
+	return s.token, nil
+}
+
+// Authorize sets the Authorization header of the request to the token provided
+// by the token source. Does nothing if the token source is nil.
+func Authorize(r *http.Request, tokens TokenSource) error {
+	if tokens == nil {
+		return nil
+	}
+
+	token, err := tokens.Token(r.Context())
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Authorization", "token "+token)
+	return nil
+}
+
+// ReadPrivateKey returns the PEM encoded private key of the Github App, either
+// from the key itself or from the file it is stored in, if the key is empty.
+// Escaped new lines ("\n") are allowed in the key, as environment variables
+// of Lambda functions cannot contain new lines.
+func ReadPrivateKey(key string, file string) ([]byte, error) {
+	if key != "" {
+		return []byte(strings.Replace(key, `\n`, "\n", -1)), nil
+	}
+	if file != "" {
+		return ioutil.ReadFile(file)
+	}
+	return nil, fmt.Errorf("Github App private key not specified")
+}
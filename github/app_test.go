@@ -0,0 +1,96 @@
+package github
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/github/githubtest"
+)
+
+// newTestAppTokenSource() returns a token source requesting tokens from the server.
+func newTestAppTokenSource(t *testing.T, server *githubtest.Server, keyPEM []byte) *AppTokenSource {
+	key, err := ParsePrivateKey(keyPEM)
+	if err != nil {
+		t.Fatalf("ParsePrivateKey() failed with error: %v", err)
+	}
+
+	return &AppTokenSource{
+		AppID:          "42",
+		InstallationID: "1000",
+		Key:            key,
+		Client:         server.Client(),
+		API:            server.URL,
+		Logger:         slog.New(slog.NewTextHandler(testLogWriter{t}, nil)),
+		Now:            time.Now,
+	}
+}
+
+func TestAppTokenSource_Token(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	source := newTestAppTokenSource(t, server, server.NewAppKey("42"))
+	now := time.Now()
+	source.Now = func() time.Time { return now }
+
+	// The token should be cached
+	first, err := source.Token(context.Background())
+	if err != nil {
+		t.Fatalf("source.Token() failed with error: %v", err)
+	}
+	second, err := source.Token(context.Background())
+	if err != nil {
+		t.Fatalf("source.Token() failed with error: %v", err)
+	}
+	if first != second || server.TokenRequests != 1 {
+		t.Errorf("Token was requested %d times, want it requested once and cached", server.TokenRequests)
+	}
+
+	// The token should be refreshed shortly before it expires
+	now = now.Add(server.TokenTTL - appTokenRefreshMargin + time.Second)
+	third, err := source.Token(context.Background())
+	if err != nil {
+		t.Fatalf("source.Token() failed with error: %v", err)
+	}
+	if third == first || server.TokenRequests != 2 {
+		t.Errorf("Token was not refreshed before expiring")
+	}
+}
+
+func TestAppTokenSource_WrongKey(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	server.NewAppKey("42")
+	otherKey := newTestServer(t).NewAppKey("42")
+
+	source := newTestAppTokenSource(t, server, otherKey)
+	_, err := source.Token(context.Background())
+	if err == nil {
+		t.Errorf("source.Token() should have failed for a JWT signed with another key")
+	}
+}
+
+func TestParsePrivateKey_Invalid(t *testing.T) {
+	_, err := ParsePrivateKey([]byte("not a key"))
+	if err == nil {
+		t.Errorf("ParsePrivateKey() should have failed for a key that is not PEM encoded")
+	}
+}
+
+func TestAppTokenSource_Cancelled(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	key := server.NewAppKey("42")
+	source := newTestAppTokenSource(t, server, key)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := source.Token(ctx)
+	if err == nil {
+		t.Errorf("source.Token() should have failed for a cancelled context")
+	}
+}
@@ -0,0 +1,216 @@
+// Package github publishes files to a Github repository, either file by file
+// through the Contents API, as a single commit through the Git Data API or
+// as a pull request.
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+)
+
+// DefaultAPI is the base URL of the public Github API.
+const DefaultAPI = "https://api.github.com"
+
+// Doer sends HTTP requests and returns their responses. *http.Client satisfies it.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Identity is the name and email of a commit author or committer.
+type Identity struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// File is a file to be committed to the Github repository.
+type File struct {
+	Path    string
+	Content []byte
+}
+
+// Client publishes files to a single Github repository.
+type Client struct {
+	// HTTP sends the requests to the Github API.
+	HTTP Doer
+	// API is the base URL of the Github API (eg. DefaultAPI).
+	API string
+	// Tokens authorizes the requests. Requests are sent unauthorized if nil.
+	Tokens TokenSource
+	Logger *slog.Logger
+	// Context is the context of all requests, if not nil, eg. for cancelling
+	// them or for propagating trace spans.
+	Context context.Context
+
+	Owner      string
+	Repository string
+	// Branch is the branch files are read from and committed to. The default
+	// branch of the repository is used if empty.
+	Branch    string
+	Committer Identity
+	// Author is the author of the commits. Github uses the committer if nil.
+	Author *Identity
+}
+
+// NewClient returns a client for the repository owner/repository, using the
+// public Github API and http.DefaultClient, authorized with the given tokens.
+func NewClient(owner string, repository string, tokens TokenSource) *Client {
+	return &Client{
+		HTTP:       http.DefaultClient,
+		API:        DefaultAPI,
+		Tokens:     tokens,
+		Logger:     loggerOrDiscard(nil),
+		Owner:      owner,
+		Repository: repository,
+	}
+}
+
+// StatusError is returned for Github API responses with an unexpected status.
+type StatusError struct {
+	Method string
+	URL    string
+	Status int
+	// Message is the body of the response.
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s failed with status %d, msg: %s", e.Method, e.URL, e.Status, e.Message)
+}
+
+// request() sends a request to the Github API, encoding in as the JSON body of
+// the request (if not nil) and decoding the JSON response into out (if not nil).
+// Returns a *StatusError if the status of the response is not one of the expected statuses.
+func (c *Client) request(method string, u string, in interface{}, out interface{}, expected ...int) error {
+	var body io.Reader
+	if in != nil {
+		j, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewBuffer(j)
+	}
+
+	r, err := c.newRequest(method, u, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+	return c.do(r, out, expected...)
+}
+
+// newRequest() returns a request with the context of the client.
+func (c *Client) newRequest(method string, u string, body io.Reader) (*http.Request, error) {
+	ctx := c.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return http.NewRequestWithContext(ctx, method, u, body)
+}
+
+// do() authorizes and sends the request, decoding the JSON response into out
+// (if not nil). Returns an error if the status of the response is not one of
+// the expected statuses.
+func (c *Client) do(r *http.Request, out interface{}, expected ...int) error {
+	err := Authorize(r, c.Tokens)
+	if err != nil {
+		return err
+	}
+
+	method, u := r.Method, r.URL.String()
+	resp, err := c.HTTP.Do(r)
+	if err != nil {
+		return fmt.Errorf("%s %s failed with error: %v", method, u, err)
+	}
+	defer resp.Body.Close()
+
+	ok := false
+	for _, status := range expected {
+		if resp.StatusCode == status {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		msg, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			msg = []byte{}
+		}
+		return &StatusError{Method: method, URL: u, Status: resp.StatusCode, Message: string(msg)}
+	}
+
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+// repoURL() returns the Github API URL of the repository.
+func (c *Client) repoURL() string {
+	return fmt.Sprintf("%s/repos/%s/%s", c.API, c.Owner, c.Repository)
+}
+
+// committer() returns the configured committer, or nil if none is configured,
+// so that Github falls back to the authenticated user instead of rejecting
+// an empty name and email.
+func (c *Client) committer() *Identity {
+	if c.Committer == (Identity{}) {
+		return nil
+	}
+	committer := c.Committer
+	return &committer
+}
+
+// logger() returns the configured logger, or a logger discarding all records
+// if none is configured.
+func (c *Client) logger() *slog.Logger {
+	return loggerOrDiscard(c.Logger)
+}
+
+// loggerOrDiscard() returns l, or a logger discarding all records if l is nil.
+func loggerOrDiscard(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		return slog.New(slog.NewTextHandler(ioutil.Discard, nil))
+	}
+	return l
+}
+
+// TargetBranch returns the configured branch, or the default branch of the
+// repository if no branch is configured.
+func (c *Client) TargetBranch() (string, error) {
+	if c.Branch != "" {
+		return c.Branch, nil
+	}
+
+	// GET /repos/:owner/:repo
+	repository := struct {
+		DefaultBranch string `json:"default_branch"`
+	}{}
+	err := c.request("GET", c.repoURL(), nil, &repository, http.StatusOK)
+	if err != nil {
+		return "", err
+	}
+	return repository.DefaultBranch, nil
+}
+
+// BranchHead returns the SHA of the commit the branch points to.
+func (c *Client) BranchHead(branch string) (string, error) {
+	// GET /repos/:owner/:repo/git/ref/heads/:branch
+	ref := struct {
+		Object struct {
+			SHA string `json:"sha"`
+		} `json:"object"`
+	}{}
+	err := c.request("GET", c.repoURL()+"/git/ref/heads/"+branch, nil, &ref, http.StatusOK)
+	if err != nil {
+		return "", err
+	}
+	return ref.Object.SHA, nil
+}
@@ -0,0 +1,61 @@
+package github
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/quasoft/changelog-nightly-parser/github/githubtest"
+)
+
+// testLogWriter writes log output of a client to the test log.
+type testLogWriter struct {
+	t *testing.T
+}
+
+func (w testLogWriter) Write(b []byte) (int, error) {
+	w.t.Log(strings.TrimRight(string(b), "\n"))
+	return len(b), nil
+}
+
+// newTestServer() starts a githubtest.Server for the user/trending-daily repository,
+// which is closed when the test completes.
+func newTestServer(t *testing.T) *githubtest.Server {
+	server := githubtest.NewServer("user", "trending-daily")
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestClient() returns a client publishing to the test server.
+func newTestClient(t *testing.T, server *githubtest.Server) *Client {
+	c := NewClient("user", "trending-daily", StaticToken("token"))
+	c.HTTP = server.Client()
+	c.API = server.URL
+	c.Logger = slog.New(slog.NewTextHandler(testLogWriter{t}, nil))
+	c.Committer = Identity{Name: "Bot", Email: "bot@example.com"}
+	return c
+}
+
+func TestClient_TargetBranch(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	c := newTestClient(t, server)
+
+	got, err := c.TargetBranch()
+	if err != nil {
+		t.Fatalf("TargetBranch() failed with error: %v", err)
+	}
+	if got != "master" {
+		t.Errorf("TargetBranch() = %s, want the default branch master", got)
+	}
+
+	c.Branch = "data"
+	got, err = c.TargetBranch()
+	if err != nil {
+		t.Fatalf("TargetBranch() failed with error: %v", err)
+	}
+	if got != "data" {
+		t.Errorf("TargetBranch() = %s, want the configured branch data", got)
+	}
+}
@@ -0,0 +1,127 @@
+package github
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GetFile retrieves a file from the Github repository and returns its content
+// and blob SHA. If the file does not exist yet, both the content and SHA are empty.
+func (c *Client) GetFile(path string) ([]byte, string, error) {
+	// Get contents (https://developer.github.com/v3/repos/contents/#get-contents):
+	// GET /repos/:owner/:repo/contents/:path
+	u := c.repoURL() + "/contents/" + path
+	if c.Branch != "" {
+		u += "?ref=" + url.QueryEscape(c.Branch)
+	}
+
+	r, err := c.newRequest("GET", u, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	err = Authorize(r, c.Tokens)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := c.HTTP.Do(r)
+	if err != nil {
+		return nil, "", fmt.Errorf("getting %s failed with error: %v", u, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			msg = []byte{}
+		}
+		return nil, "", fmt.Errorf("getting %s failed with status %d, msg: %s", u, resp.StatusCode, string(msg))
+	}
+
+	file := struct {
+		SHA     string `json:"sha"`
+		Content string `json:"content"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&file)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Github wraps base64 content on multiple lines
+	content, err := base64.StdEncoding.DecodeString(strings.Replace(file.Content, "\n", "", -1))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return content, file.SHA, nil
+}
+
+// UploadFile creates or updates the file at the given path in the Github
+// repository, committing it with the given message.
+func (c *Client) UploadFile(body []byte, path string, message string) error {
+	// Updating an existing file requires the blob SHA of the file being replaced
+	_, sha, err := c.GetFile(path)
+	if err != nil {
+		return err
+	}
+
+	// Create or update a file (https://developer.github.com/v3/repos/contents/#update-a-file):
+	// PUT /repos/:owner/:repo/contents/:path
+	u := c.repoURL() + "/contents/" + path
+
+	params := struct {
+		Message  string    `json:"message"`
+		Content  string    `json:"content"`
+		SHA      string    `json:"sha,omitempty"`
+		Branch   string    `json:"branch,omitempty"`
+		Commiter *Identity `json:"committer,omitempty"`
+		Author   *Identity `json:"author,omitempty"`
+	}{}
+	params.Message = message
+	params.Content = base64.StdEncoding.EncodeToString(body)
+	params.SHA = sha
+	params.Branch = c.Branch
+	params.Commiter = c.committer()
+	params.Author = c.Author
+
+	j, err := json.MarshalIndent(params, "", "    ")
+	if err != nil {
+		return err
+	}
+	buf := bytes.NewBuffer(j)
+
+	r, err := c.newRequest("PUT", u, buf)
+	if err != nil {
+		return err
+	}
+	err = Authorize(r, c.Tokens)
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := c.HTTP.Do(r)
+	if err != nil {
+		return fmt.Errorf("uploading to %s failed with error: %v", u, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		msg, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			msg = []byte{}
+		}
+		return fmt.Errorf("uploading to %s failed with status %d, msg: %s", u, resp.StatusCode, string(msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package github
+
+import (
+	"testing"
+)
+
+func TestClient_UploadFile(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	c := newTestClient(t, server)
+
+	// Uploading twice should update the file the second time
+	for _, content := range []string{"A", "B"} {
+		err := c.UploadFile([]byte(content), "dir/a.json", "Upload "+content)
+		if err != nil {
+			t.Fatalf("UploadFile() failed with error: %v", err)
+		}
+	}
+
+	got, sha, err := c.GetFile("dir/a.json")
+	if err != nil {
+		t.Fatalf("GetFile() failed with error: %v", err)
+	}
+	if string(got) != "B" || sha == "" {
+		t.Errorf("GetFile() = %q, %q, want the updated content and its SHA", got, sha)
+	}
+	if got := server.CommitCount("master"); got != 3 {
+		t.Errorf("Branch has %d commits, want %d", got, 3)
+	}
+
+	got, sha, err = c.GetFile("missing.json")
+	if err != nil || got != nil || sha != "" {
+		t.Errorf("GetFile() = %q, %q, %v for a missing file, want no content and no error", got, sha, err)
+	}
+}
@@ -0,0 +1,100 @@
+package github
+
+import (
+	"encoding/base64"
+	"net/http"
+)
+
+// Commit commits all files to the given branch of the Github repository
+// in a single commit, using the Git Data API (https://developer.github.com/v3/git/):
+// 1. Get the commit the branch points to and its tree
+// 2. Create a blob for each file
+// 3. Create a new tree with the blobs, based on the tree of the current commit
+// 4. Create a commit with the new tree, having the current commit as parent
+// 5. Move the branch to the new commit
+func (c *Client) Commit(branch string, files []File, message string) error {
+	base := c.repoURL()
+
+	// 1. Get the commit the branch points to
+	parent, err := c.BranchHead(branch)
+	if err != nil {
+		return err
+	}
+
+	// GET /repos/:owner/:repo/git/commits/:sha
+	commit := struct {
+		SHA  string `json:"sha"`
+		Tree struct {
+			SHA string `json:"sha"`
+		} `json:"tree"`
+	}{}
+	err = c.request("GET", base+"/git/commits/"+parent, nil, &commit, http.StatusOK)
+	if err != nil {
+		return err
+	}
+
+	// 2. POST /repos/:owner/:repo/git/blobs
+	type treeEntry struct {
+		Path string `json:"path"`
+		Mode string `json:"mode"`
+		Type string `json:"type"`
+		SHA  string `json:"sha"`
+	}
+	entries := []treeEntry{}
+	for _, f := range files {
+		blob := struct {
+			SHA string `json:"sha"`
+		}{}
+		params := map[string]string{
+			"content":  base64.StdEncoding.EncodeToString(f.Content),
+			"encoding": "base64",
+		}
+		err = c.request("POST", base+"/git/blobs", params, &blob, http.StatusCreated)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, treeEntry{Path: f.Path, Mode: "100644", Type: "blob", SHA: blob.SHA})
+	}
+
+	// 3. POST /repos/:owner/:repo/git/trees
+	tree := struct {
+		SHA string `json:"sha"`
+	}{}
+	treeParams := struct {
+		BaseTree string      `json:"base_tree"`
+		Tree     []treeEntry `json:"tree"`
+	}{commit.Tree.SHA, entries}
+	err = c.request("POST", base+"/git/trees", treeParams, &tree, http.StatusCreated)
+	if err != nil {
+		return err
+	}
+
+	// 4. POST /repos/:owner/:repo/git/commits
+	newCommit := struct {
+		SHA string `json:"sha"`
+	}{}
+	commitParams := struct {
+		Message  string    `json:"message"`
+		Tree     string    `json:"tree"`
+		Parents  []string  `json:"parents"`
+		Commiter *Identity `json:"committer,omitempty"`
+		Author   *Identity `json:"author,omitempty"`
+	}{message, tree.SHA, []string{parent}, c.committer(), c.Author}
+	err = c.request("POST", base+"/git/commits", commitParams, &newCommit, http.StatusCreated)
+	if err != nil {
+		return err
+	}
+
+	// 5. PATCH /repos/:owner/:repo/git/refs/heads/:branch
+	refParams := struct {
+		SHA   string `json:"sha"`
+		Force bool   `json:"force"`
+	}{newCommit.SHA, false}
+	err = c.request("PATCH", base+"/git/refs/heads/"+branch, refParams, nil, http.StatusOK)
+	if err != nil {
+		return err
+	}
+
+	c.logger().Info("Committed files", "files", len(files), "repository", c.Owner+"/"+c.Repository, "branch", branch, "sha", newCommit.SHA)
+	return nil
+}
@@ -0,0 +1,93 @@
+package github
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestClient_Commit(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	c := newTestClient(t, server)
+
+	err := c.Commit("master", []File{{Path: "a.json", Content: []byte("A")}}, "First")
+	if err != nil {
+		t.Fatalf("Commit() failed with error: %v", err)
+	}
+
+	files := []File{
+		{Path: "b.json", Content: []byte("B")},
+		{Path: "dir/c.json", Content: []byte("C")},
+	}
+	err = c.Commit("master", files, "Second")
+	if err != nil {
+		t.Fatalf("Commit() failed with error: %v", err)
+	}
+
+	gotCommits := server.CommitCount("master")
+	wantCommits := 3
+	if gotCommits != wantCommits {
+		t.Errorf("Branch has %d commits, want %d", gotCommits, wantCommits)
+	}
+
+	gotMessage := server.Head("master").Message
+	if gotMessage != "Second" {
+		t.Errorf("Last commit message = %q, want %q", gotMessage, "Second")
+	}
+
+	// Files from the previous commit should be kept
+	want := map[string]string{"a.json": "A", "b.json": "B", "dir/c.json": "C"}
+	for path, content := range want {
+		got, ok := server.File("master", path)
+		if !ok {
+			t.Errorf("%s is missing from the branch", path)
+		} else if string(got) != content {
+			t.Errorf("%s = %q, want %q", path, got, content)
+		}
+	}
+}
+
+func TestClient_Commit_Fail(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	server.FailWrites = http.StatusForbidden
+	c := newTestClient(t, server)
+
+	err := c.Commit("master", []File{{Path: "a.json", Content: []byte("A")}}, "First")
+	if err == nil {
+		t.Fatalf("Should have returned an error when the Git Data API replies with an error.")
+	}
+
+	gotCommits := server.CommitCount("master")
+	if gotCommits != 1 {
+		t.Errorf("Branch has %d commits, want %d", gotCommits, 1)
+	}
+}
+
+func TestClient_Commit_NoCommitter(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	c := NewClient("user", "trending-daily", StaticToken("token"))
+	c.HTTP = server.Client()
+	c.API = server.URL
+	c.Logger = nil
+
+	err := c.Commit("master", []File{{Path: "a.json", Content: []byte("A")}}, "First")
+	if err != nil {
+		t.Fatalf("Commit() failed with error: %v", err)
+	}
+	if got := server.Head("master").Committer; got != nil {
+		t.Errorf("Commit() sent committer %v, want it omitted when not configured", got)
+	}
+
+	err = c.UploadFile([]byte("B"), "b.json", "Second")
+	if err != nil {
+		t.Fatalf("UploadFile() failed with error: %v", err)
+	}
+	if got := server.Head("master").Committer; got != nil {
+		t.Errorf("UploadFile() sent committer %v, want it omitted when not configured", got)
+	}
+}
@@ -0,0 +1,141 @@
+package githubtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// graphqlRepositoryField matches the aliased repository fields of a query,
+	// followed by the rest of the line.
+	graphqlRepositoryField = regexp.MustCompile(`(\w+): repository\(owner: \$(\w+), name: \$(\w+)\)(.*)`)
+	// graphqlObjectField matches the aliased object fields of a query with a
+	// literal expression.
+	graphqlObjectField = regexp.MustCompile(`(\w+): object\(expression: "HEAD:([^"]*)"\)`)
+	// graphqlObjectVariable matches the aliased object fields of a query with
+	// the expression in a variable.
+	graphqlObjectVariable = regexp.MustCompile(`(\w+): object\(expression: \$(\w+)\)`)
+)
+
+// serveGraphQL() answers queries with aliased repository fields, for the
+// repositories added with AddRepository. The fields of the repositories are
+// converted from the REST representation, regardless of the fields selected
+// by the query, except for objects that are only returned when requested:
+// files added with AddReadme and the directories containing them. Objects with
+// a literal expression are returned for every repository, objects with the
+// expression in a variable only for the repository on the same line.
+// Like Github, GraphQL requests without a token are rejected.
+func (s *Server) serveGraphQL(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") == "" {
+		s.reply(w, http.StatusUnauthorized, map[string]string{"message": "This endpoint requires you to be authenticated."})
+		return
+	}
+
+	var in struct {
+		Query     string
+		Variables map[string]string
+	}
+	err := json.NewDecoder(r.Body).Decode(&in)
+	if err != nil {
+		s.reply(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+		return
+	}
+
+	objects := graphqlObjectField.FindAllStringSubmatch(in.Query, -1)
+	data := map[string]interface{}{}
+	errors := []map[string]interface{}{}
+	for _, m := range graphqlRepositoryField.FindAllStringSubmatch(in.Query, -1) {
+		alias := m[1]
+		fullName := in.Variables[m[2]] + "/" + in.Variables[m[3]]
+		info, ok := s.repositories[fullName]
+		if !ok {
+			data[alias] = nil
+			errors = append(errors, map[string]interface{}{
+				"type":    "NOT_FOUND",
+				"path":    []string{alias},
+				"message": fmt.Sprintf("Could not resolve to a Repository with the name '%s'.", fullName),
+			})
+			continue
+		}
+
+		repo := graphqlRepositoryOf(info)
+		for _, o := range objects {
+			repo[o[1]] = s.graphqlObject(fullName, o[2])
+		}
+		for _, o := range graphqlObjectVariable.FindAllStringSubmatch(m[4], -1) {
+			repo[o[1]] = s.graphqlObject(fullName, strings.TrimPrefix(in.Variables[o[2]], "HEAD:"))
+		}
+		data[alias] = repo
+	}
+
+	out := map[string]interface{}{"data": data}
+	if len(errors) > 0 {
+		out["errors"] = errors
+	}
+	s.reply(w, http.StatusOK, out)
+}
+
+// graphqlObject() returns the file at p added with AddReadme as a blob, or the
+// directory at p as a tree listing the files added in it, or nil if there is
+// no such file or directory. The root directory always exists.
+func (s *Server) graphqlObject(fullName string, p string) interface{} {
+	if text, ok := s.readmes[fullName][p]; ok {
+		return map[string]string{"text": text}
+	}
+
+	entries := []map[string]string{}
+	for file := range s.readmes[fullName] {
+		dir := path.Dir(file)
+		if dir == "." {
+			dir = ""
+		}
+		if dir == p {
+			entries = append(entries, map[string]string{"name": path.Base(file), "type": "blob"})
+		}
+	}
+	if len(entries) == 0 && p != "" {
+		return nil
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i]["name"] < entries[j]["name"] })
+	return map[string]interface{}{"entries": entries}
+}
+
+// graphqlRepositoryOf() converts the REST representation of a repository to GraphQL.
+func graphqlRepositoryOf(info map[string]interface{}) map[string]interface{} {
+	topics := []interface{}{}
+	if names, ok := info["topics"].([]string); ok {
+		for _, name := range names {
+			topics = append(topics, map[string]interface{}{"topic": map[string]string{"name": name}})
+		}
+	}
+
+	var license interface{}
+	if l, ok := info["license"].(map[string]string); ok {
+		license = map[string]string{"key": l["key"], "name": l["name"], "spdxId": l["spdx_id"]}
+	}
+
+	branch := "master"
+	if b, ok := info["default_branch"].(string); ok {
+		branch = b
+	}
+
+	return map[string]interface{}{
+		"nameWithOwner":    info["full_name"],
+		"description":      info["description"],
+		"homepageUrl":      info["homepage"],
+		"repositoryTopics": map[string]interface{}{"nodes": topics},
+		"licenseInfo":      license,
+		"stargazerCount":   info["stargazers_count"],
+		"forkCount":        info["forks_count"],
+		"issues":           map[string]interface{}{"totalCount": info["open_issues_count"]},
+		"isArchived":       info["archived"],
+		"createdAt":        info["created_at"],
+		"pushedAt":         info["pushed_at"],
+		"defaultBranchRef": map[string]string{"name": branch},
+	}
+}
@@ -0,0 +1,551 @@
+// Package githubtest provides an in-memory Github API server for testing code
+// that publishes to Github.
+package githubtest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Commit is a commit stored in Server.
+type Commit struct {
+	Message   string
+	Tree      string
+	Parents   []string
+	Committer map[string]string
+	Author    map[string]string
+}
+
+// Pull is a pull request stored in Server.
+type Pull struct {
+	Number int
+	Title  string
+	Body   string
+	Head   string
+	Base   string
+	Labels []string
+	Merged bool
+}
+
+// Server is an in-memory stand-in for the parts of the Github API used for
+// uploads: the Contents API, the Git Data API and the Pulls API of a single repository.
+// Trees are stored as maps from path to blob SHA.
+type Server struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	owner         string
+	repo          string
+	defaultBranch string
+	branches      map[string]string
+	commits       map[string]Commit
+	trees         map[string]map[string]string
+	blobs         map[string][]byte
+	pulls         []*Pull
+	// repositories are other repositories, as returned by GET /repos/:owner/:repo.
+	repositories map[string]map[string]interface{}
+	// readmes are the readme files of other repositories, by path.
+	readmes map[string]map[string]string
+
+	// Requests records every request received, as "METHOD /path".
+	Requests []string
+	// FailWrites makes all non-GET requests fail with this status, if not zero.
+	FailWrites int
+
+	// AppID and AppKey are used to verify the JWT of Github App token requests.
+	AppID  string
+	AppKey *rsa.PublicKey
+	// TokenRequests counts the installation tokens issued, each one valid for TokenTTL.
+	TokenRequests int
+	TokenTTL      time.Duration
+	// Now is the clock used for validating JWTs and issuing tokens.
+	Now func() time.Time
+	// RequireToken makes all repository requests without this token fail with 401, if not empty.
+	RequireToken string
+}
+
+// NewServer starts a stub Github API server for the repository owner/repo,
+// with a single initial commit on the master branch.
+// The server should be closed with Close when no longer used.
+func NewServer(owner, repo string) *Server {
+	s := &Server{
+		owner:         owner,
+		repo:          repo,
+		defaultBranch: "master",
+		branches:      map[string]string{},
+		commits:       map[string]Commit{},
+		trees:         map[string]map[string]string{},
+		blobs:         map[string][]byte{},
+		repositories:  map[string]map[string]interface{}{},
+		readmes:       map[string]map[string]string{},
+		TokenTTL:      time.Hour,
+		Now:           time.Now,
+	}
+	tree := s.putTree(map[string]string{})
+	s.branches["master"] = s.putCommit(Commit{Message: "Initial commit", Tree: tree})
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func hashOf(kind string, v interface{}) string {
+	j, _ := json.Marshal(v)
+	return fmt.Sprintf("%x", sha1.Sum(append([]byte(kind), j...)))
+}
+
+func (s *Server) putBlob(content []byte) string {
+	sha := hashOf("blob", content)
+	s.blobs[sha] = content
+	return sha
+}
+
+func (s *Server) putTree(tree map[string]string) string {
+	sha := hashOf("tree", tree)
+	s.trees[sha] = tree
+	return sha
+}
+
+func (s *Server) putCommit(c Commit) string {
+	sha := hashOf(fmt.Sprintf("commit%d", len(s.commits)), c)
+	s.commits[sha] = c
+	return sha
+}
+
+// File returns the content of the file at path on the given branch.
+func (s *Server) File(branch string, path string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tree := s.trees[s.commits[s.branches[branch]].Tree]
+	blob, ok := tree[path]
+	if !ok {
+		return nil, false
+	}
+	return s.blobs[blob], true
+}
+
+// CreateBranch creates a branch pointing to the same commit as the default branch.
+func (s *Server) CreateBranch(branch string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.branches[branch] = s.branches[s.defaultBranch]
+}
+
+// Head returns the commit the given branch points to.
+func (s *Server) Head(branch string) Commit {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.commits[s.branches[branch]]
+}
+
+// CommitCount returns the number of commits on the given branch.
+func (s *Server) CommitCount(branch string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for sha := s.branches[branch]; sha != ""; count++ {
+		parents := s.commits[sha].Parents
+		if len(parents) == 0 {
+			sha = ""
+		} else {
+			sha = parents[0]
+		}
+	}
+	return count
+}
+
+// AddRepository adds another repository to the server, replied as info to
+// GET /repos/:owner/:repo, where fullName is "owner/repo".
+func (s *Server) AddRepository(fullName string, info map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.repositories[fullName] = info
+}
+
+// AddReadme adds a file at path to another repository, returned by GraphQL
+// queries for the object "HEAD:<path>" of the repository, and listed in the
+// tree of its directory.
+func (s *Server) AddReadme(fullName string, path string, text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.readmes[fullName] == nil {
+		s.readmes[fullName] = map[string]string{}
+	}
+	s.readmes[fullName][path] = text
+}
+
+// NewAppKey generates a private key for the Github App appID and registers its
+// public key with the server, returning the key in PEM format. It panics if the
+// key cannot be generated.
+func (s *Server) NewAppKey(appID string) []byte {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(fmt.Sprintf("githubtest: generating RSA key: %v", err))
+	}
+	s.AppID = appID
+	s.AppKey = &key.PublicKey
+
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+// Pulls returns a copy of all pull requests opened so far, in the order they were opened.
+func (s *Server) Pulls() []Pull {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pulls := []Pull{}
+	for _, pull := range s.pulls {
+		pulls = append(pulls, *pull)
+	}
+	return pulls
+}
+
+// identityOf returns the name and email of the author or committer in the
+// request parameters, or nil if the identity is not specified.
+func identityOf(in map[string]interface{}, key string) map[string]string {
+	identity, ok := in[key].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	m := map[string]string{}
+	for k, v := range identity {
+		m[k] = fmt.Sprint(v)
+	}
+	return m
+}
+
+// invalidIdentity returns true if the author or committer in the request
+// parameters is specified, but lacks a name or email, which Github rejects.
+func invalidIdentity(in map[string]interface{}) bool {
+	for _, key := range []string{"author", "committer"} {
+		identity := identityOf(in, key)
+		if identity != nil && (identity["name"] == "" || identity["email"] == "") {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) reply(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Requests = append(s.Requests, r.Method+" "+r.URL.Path)
+
+	if s.FailWrites != 0 && r.Method != "GET" {
+		s.reply(w, s.FailWrites, map[string]string{"message": "Stub failure"})
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, "/app/installations/") && r.Method == "POST" {
+		s.serveAccessToken(w, r)
+		return
+	}
+
+	if s.RequireToken != "" && r.Header.Get("Authorization") != "token "+s.RequireToken {
+		s.reply(w, http.StatusUnauthorized, map[string]string{"message": "Bad credentials"})
+		return
+	}
+
+	if r.URL.Path == "/graphql" && r.Method == "POST" {
+		s.serveGraphQL(w, r)
+		return
+	}
+
+	if info, ok := s.repositories[strings.TrimPrefix(r.URL.Path, "/repos/")]; ok && r.Method == "GET" {
+		s.reply(w, http.StatusOK, info)
+		return
+	}
+
+	prefix := fmt.Sprintf("/repos/%s/%s", s.owner, s.repo)
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		s.reply(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+	p := strings.TrimPrefix(r.URL.Path, prefix)
+
+	var in map[string]interface{}
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&in)
+	}
+
+	switch {
+	case p == "" && r.Method == "GET":
+		s.reply(w, http.StatusOK, map[string]string{"default_branch": s.defaultBranch})
+
+	case strings.HasPrefix(p, "/contents/"):
+		s.serveContents(w, r, strings.TrimPrefix(p, "/contents/"), in)
+
+	case strings.HasPrefix(p, "/git/ref/heads/") && r.Method == "GET":
+		sha, ok := s.branches[strings.TrimPrefix(p, "/git/ref/heads/")]
+		if !ok {
+			s.reply(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		s.reply(w, http.StatusOK, map[string]interface{}{"object": map[string]string{"sha": sha}})
+
+	case strings.HasPrefix(p, "/git/commits/") && r.Method == "GET":
+		sha := strings.TrimPrefix(p, "/git/commits/")
+		c, ok := s.commits[sha]
+		if !ok {
+			s.reply(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		s.reply(w, http.StatusOK, map[string]interface{}{"sha": sha, "tree": map[string]string{"sha": c.Tree}})
+
+	case p == "/git/blobs" && r.Method == "POST":
+		content, err := base64.StdEncoding.DecodeString(in["content"].(string))
+		if err != nil {
+			s.reply(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+			return
+		}
+		s.reply(w, http.StatusCreated, map[string]string{"sha": s.putBlob(content)})
+
+	case p == "/git/trees" && r.Method == "POST":
+		tree := map[string]string{}
+		if base, ok := in["base_tree"].(string); ok {
+			for k, v := range s.trees[base] {
+				tree[k] = v
+			}
+		}
+		for _, e := range in["tree"].([]interface{}) {
+			entry := e.(map[string]interface{})
+			tree[entry["path"].(string)] = entry["sha"].(string)
+		}
+		s.reply(w, http.StatusCreated, map[string]string{"sha": s.putTree(tree)})
+
+	case p == "/git/commits" && r.Method == "POST":
+		if invalidIdentity(in) {
+			s.reply(w, http.StatusUnprocessableEntity, map[string]string{"message": "Invalid request"})
+			return
+		}
+		c := Commit{
+			Message:   in["message"].(string),
+			Tree:      in["tree"].(string),
+			Committer: identityOf(in, "committer"),
+			Author:    identityOf(in, "author"),
+		}
+		for _, parent := range in["parents"].([]interface{}) {
+			c.Parents = append(c.Parents, parent.(string))
+		}
+		s.reply(w, http.StatusCreated, map[string]string{"sha": s.putCommit(c)})
+
+	case strings.HasPrefix(p, "/git/refs/heads/") && r.Method == "PATCH":
+		branch := strings.TrimPrefix(p, "/git/refs/heads/")
+		sha := in["sha"].(string)
+		if _, ok := s.branches[branch]; !ok {
+			s.reply(w, http.StatusUnprocessableEntity, map[string]string{"message": "Reference does not exist"})
+			return
+		}
+		s.branches[branch] = sha
+		s.reply(w, http.StatusOK, map[string]interface{}{"object": map[string]string{"sha": sha}})
+
+	case p == "/git/refs" && r.Method == "POST":
+		branch := strings.TrimPrefix(in["ref"].(string), "refs/heads/")
+		if _, ok := s.branches[branch]; ok {
+			s.reply(w, http.StatusUnprocessableEntity, map[string]string{"message": "Reference already exists"})
+			return
+		}
+		s.branches[branch] = in["sha"].(string)
+		s.reply(w, http.StatusCreated, map[string]interface{}{"object": map[string]string{"sha": s.branches[branch]}})
+
+	case p == "/pulls" && r.Method == "GET":
+		q := r.URL.Query()
+		pulls := []map[string]interface{}{}
+		for _, pull := range s.pulls {
+			if pull.Merged || (q.Get("head") != "" && s.owner+":"+pull.Head != q.Get("head")) ||
+				(q.Get("base") != "" && pull.Base != q.Get("base")) {
+				continue
+			}
+			pulls = append(pulls, s.pullJSON(pull))
+		}
+		s.reply(w, http.StatusOK, pulls)
+
+	case p == "/pulls" && r.Method == "POST":
+		pull := &Pull{
+			Number: len(s.pulls) + 1,
+			Title:  in["title"].(string),
+			Body:   in["body"].(string),
+			Head:   in["head"].(string),
+			Base:   in["base"].(string),
+		}
+		s.pulls = append(s.pulls, pull)
+		s.reply(w, http.StatusCreated, s.pullJSON(pull))
+
+	case strings.HasPrefix(p, "/issues/") && strings.HasSuffix(p, "/labels") && r.Method == "POST":
+		pull := s.pullByPath(strings.TrimSuffix(strings.TrimPrefix(p, "/issues/"), "/labels"))
+		if pull == nil {
+			s.reply(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		for _, label := range in["labels"].([]interface{}) {
+			if !strings.Contains(","+strings.Join(pull.Labels, ",")+",", ","+label.(string)+",") {
+				pull.Labels = append(pull.Labels, label.(string))
+			}
+		}
+		s.reply(w, http.StatusOK, pull.Labels)
+
+	case strings.HasPrefix(p, "/pulls/") && strings.HasSuffix(p, "/merge") && r.Method == "PUT":
+		pull := s.pullByPath(strings.TrimSuffix(strings.TrimPrefix(p, "/pulls/"), "/merge"))
+		if pull == nil {
+			s.reply(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		head := s.commits[s.branches[pull.Head]]
+		sha := s.putCommit(Commit{
+			Message: "Merge pull request #" + fmt.Sprint(pull.Number),
+			Tree:    head.Tree,
+			Parents: []string{s.branches[pull.Base], s.branches[pull.Head]},
+		})
+		s.branches[pull.Base] = sha
+		pull.Merged = true
+		s.reply(w, http.StatusOK, map[string]interface{}{"sha": sha, "merged": true})
+
+	default:
+		s.reply(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+	}
+}
+
+// serveAccessToken verifies the JWT signed by the Github App and issues a new
+// installation token.
+func (s *Server) serveAccessToken(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".")
+	if s.AppKey == nil || len(parts) != 3 {
+		s.reply(w, http.StatusUnauthorized, map[string]string{"message": "A JSON web token could not be decoded"})
+		return
+	}
+
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	if rsa.VerifyPKCS1v15(s.AppKey, crypto.SHA256, hash[:], signature) != nil {
+		s.reply(w, http.StatusUnauthorized, map[string]string{"message": "JWT signature does not match"})
+		return
+	}
+
+	claims := struct {
+		Iss string `json:"iss"`
+		Exp int64  `json:"exp"`
+	}{}
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	json.Unmarshal(payload, &claims)
+	if claims.Iss != s.AppID || claims.Exp < s.Now().Unix() {
+		s.reply(w, http.StatusUnauthorized, map[string]string{"message": "JWT is expired or issued by another App"})
+		return
+	}
+
+	s.TokenRequests++
+	s.reply(w, http.StatusCreated, map[string]interface{}{
+		"token":      fmt.Sprintf("installation-token-%d", s.TokenRequests),
+		"expires_at": s.Now().Add(s.TokenTTL).UTC().Format(time.RFC3339),
+	})
+}
+
+func (s *Server) pullJSON(pull *Pull) map[string]interface{} {
+	return map[string]interface{}{
+		"number":   pull.Number,
+		"html_url": fmt.Sprintf("https://github.com/%s/%s/pull/%d", s.owner, s.repo, pull.Number),
+		"head":     map[string]string{"ref": pull.Head},
+		"base":     map[string]string{"ref": pull.Base},
+	}
+}
+
+func (s *Server) pullByPath(number string) *Pull {
+	for _, pull := range s.pulls {
+		if fmt.Sprint(pull.Number) == number {
+			return pull
+		}
+	}
+	return nil
+}
+
+func (s *Server) serveContents(w http.ResponseWriter, r *http.Request, path string, in map[string]interface{}) {
+	branch := s.defaultBranch
+	if ref := r.URL.Query().Get("ref"); ref != "" {
+		branch = ref
+	}
+	if b, ok := in["branch"].(string); ok && b != "" {
+		branch = b
+	}
+	if _, ok := s.branches[branch]; !ok {
+		s.reply(w, http.StatusNotFound, map[string]string{"message": "No commit found for the ref " + branch})
+		return
+	}
+	head := s.commits[s.branches[branch]]
+	tree := s.trees[head.Tree]
+	blob, exists := tree[path]
+
+	switch r.Method {
+	case "GET":
+		if !exists {
+			s.reply(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		s.reply(w, http.StatusOK, map[string]string{
+			"sha":     blob,
+			"content": base64.StdEncoding.EncodeToString(s.blobs[blob]),
+		})
+
+	case "PUT":
+		if invalidIdentity(in) {
+			s.reply(w, http.StatusUnprocessableEntity, map[string]string{"message": "Invalid request"})
+			return
+		}
+		sha, _ := in["sha"].(string)
+		if exists && sha != blob {
+			s.reply(w, http.StatusConflict, map[string]string{"message": "sha does not match"})
+			return
+		}
+		content, err := base64.StdEncoding.DecodeString(in["content"].(string))
+		if err != nil {
+			s.reply(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+			return
+		}
+
+		newTree := map[string]string{}
+		for k, v := range tree {
+			newTree[k] = v
+		}
+		newTree[path] = s.putBlob(content)
+		s.branches[branch] = s.putCommit(Commit{
+			Message:   in["message"].(string),
+			Tree:      s.putTree(newTree),
+			Parents:   []string{s.branches[branch]},
+			Committer: identityOf(in, "committer"),
+			Author:    identityOf(in, "author"),
+		})
+
+		status := http.StatusCreated
+		if exists {
+			status = http.StatusOK
+		}
+		s.reply(w, status, map[string]interface{}{"content": map[string]string{"sha": newTree[path]}})
+
+	default:
+		s.reply(w, http.StatusMethodNotAllowed, map[string]string{"message": "Method not allowed"})
+	}
+}
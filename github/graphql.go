@@ -0,0 +1,292 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// GraphQLBatchSize is the maximum number of repositories requested with a
+// single GraphQL query by GetRepositories.
+const GraphQLBatchSize = 50
+
+// readmeDirs are the directories searched for a readme by GetRepositories, in
+// the order Github prefers them. GraphQL can't find the readme of a repository
+// by itself, so the directories are listed first and the readme read with a
+// second query.
+var readmeDirs = []string{".github", "", "docs"}
+
+// repositoryFragment selects the fields of a repository read by GetRepositories.
+var repositoryFragment = func() string {
+	var b strings.Builder
+	b.WriteString(`fragment repo on Repository {
+  nameWithOwner
+  description
+  homepageUrl
+  repositoryTopics(first: 20) { nodes { topic { name } } }
+  licenseInfo { key name spdxId }
+  stargazerCount
+  forkCount
+  issues(states: OPEN) { totalCount }
+  isArchived
+  createdAt
+  pushedAt
+  defaultBranchRef { name }
+`)
+	for i, dir := range readmeDirs {
+		fmt.Fprintf(&b, "  readmeDir%d: object(expression: \"HEAD:%s\") { ... on Tree { entries { name type } } }\n", i, dir)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}()
+
+// graphqlRepository is a repository, as returned by the repositoryFragment.
+type graphqlRepository struct {
+	NameWithOwner    string
+	Description      string
+	HomepageURL      string `json:"homepageUrl"`
+	RepositoryTopics struct {
+		Nodes []struct {
+			Topic struct {
+				Name string
+			}
+		}
+	}
+	LicenseInfo *struct {
+		Key    string
+		Name   string
+		SPDXID string `json:"spdxId"`
+	}
+	StargazerCount int
+	ForkCount      int
+	Issues         struct {
+		TotalCount int
+	}
+	IsArchived       bool
+	CreatedAt        time.Time
+	PushedAt         time.Time
+	DefaultBranchRef *struct {
+		Name string
+	}
+	ReadmeDir0 *graphqlTree
+	ReadmeDir1 *graphqlTree
+	ReadmeDir2 *graphqlTree
+}
+
+// graphqlTree is the list of files in a directory, as returned by the object field.
+type graphqlTree struct {
+	Entries []struct {
+		Name string
+		Type string
+	}
+}
+
+// graphqlBlob is the text of a file, as returned by the object field.
+type graphqlBlob struct {
+	Text string
+}
+
+// repository() converts the GraphQL repository to the type returned by the REST API.
+// The readme is not populated.
+func (g *graphqlRepository) repository() *Repository {
+	repo := &Repository{
+		FullName:    g.NameWithOwner,
+		Description: g.Description,
+		Homepage:    g.HomepageURL,
+		Topics:      []string{},
+		Stars:       g.StargazerCount,
+		Forks:       g.ForkCount,
+		OpenIssues:  g.Issues.TotalCount,
+		Archived:    g.IsArchived,
+		CreatedAt:   g.CreatedAt,
+		PushedAt:    g.PushedAt,
+	}
+	for _, node := range g.RepositoryTopics.Nodes {
+		repo.Topics = append(repo.Topics, node.Topic.Name)
+	}
+	if g.LicenseInfo != nil {
+		repo.License = &License{Key: g.LicenseInfo.Key, Name: g.LicenseInfo.Name, SPDXID: g.LicenseInfo.SPDXID}
+	}
+	if g.DefaultBranchRef != nil {
+		repo.DefaultBranch = g.DefaultBranchRef.Name
+	}
+	return repo
+}
+
+// readmePath() returns the path of the readme of the repository, or an empty
+// string if none of the readmeDirs contains one. Like Github, a file named
+// README, in any case and with any extension, is a readme, and Markdown readmes
+// are preferred over other ones in the same directory.
+func (g *graphqlRepository) readmePath() string {
+	for i, tree := range []*graphqlTree{g.ReadmeDir0, g.ReadmeDir1, g.ReadmeDir2} {
+		if tree == nil {
+			continue
+		}
+
+		found := ""
+		for _, e := range tree.Entries {
+			name := strings.ToLower(e.Name)
+			if e.Type != "blob" || (name != "readme" && !strings.HasPrefix(name, "readme.")) {
+				continue
+			}
+			if found == "" || (isMarkdown(name) && !isMarkdown(strings.ToLower(found))) {
+				found = e.Name
+			}
+		}
+		if found != "" {
+			return path.Join(readmeDirs[i], found)
+		}
+	}
+	return ""
+}
+
+// isMarkdown() returns true if the file name has a Markdown extension.
+func isMarkdown(name string) bool {
+	switch path.Ext(name) {
+	case ".md", ".markdown", ".mdown", ".mkdn":
+		return true
+	}
+	return false
+}
+
+// graphqlError is an error reported in the errors array of a GraphQL response.
+type graphqlError struct {
+	Type    string
+	Path    []interface{}
+	Message string
+}
+
+// GetRepositories returns the metadata, default branch and readme of the
+// repositories with the given names ("owner/name"), using as few GraphQL queries
+// as possible: one for the metadata and one for the readmes of each batch of
+// GraphQLBatchSize repositories. The readme is the Markdown source of the same
+// file the readme API (GET /repos/:owner/:repo/readme) returns.
+// The returned map is keyed by the requested names. Repositories that don't
+// exist are missing from the map.
+// Returns an error if any of the queries fails as a whole, e.g. because GraphQL
+// is not available or the client is not authorized (GraphQL requires a token).
+func (c *Client) GetRepositories(names []string) (map[string]*Repository, error) {
+	repos := map[string]*Repository{}
+	for start := 0; start < len(names); start += GraphQLBatchSize {
+		end := start + GraphQLBatchSize
+		if end > len(names) {
+			end = len(names)
+		}
+		paths, err := c.getRepositories(names[start:end], repos)
+		if err != nil {
+			return nil, err
+		}
+		err = c.getReadmes(names[start:end], paths, repos)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return repos, nil
+}
+
+// getRepositories() requests a single batch of repositories, with one aliased
+// repository field per name, and adds them to repos. Returns the paths of the
+// readmes of the repositories, keyed by name.
+func (c *Client) getRepositories(names []string, repos map[string]*Repository) (map[string]string, error) {
+	var query strings.Builder
+	params := []string{}
+	variables := map[string]string{}
+	for i, name := range names {
+		parts := strings.Split(name, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("Invalid repository name %q, expected owner/name", name)
+		}
+		params = append(params, fmt.Sprintf("$o%d: String!, $n%d: String!", i, i))
+		variables[fmt.Sprintf("o%d", i)] = parts[0]
+		variables[fmt.Sprintf("n%d", i)] = parts[1]
+		fmt.Fprintf(&query, "  r%d: repository(owner: $o%d, name: $n%d) { ...repo }\n", i, i, i)
+	}
+
+	data := map[string]*graphqlRepository{}
+	err := c.graphql(fmt.Sprintf("query(%s) {\n%s}\n%s", strings.Join(params, ", "), query.String(), repositoryFragment), variables, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := map[string]string{}
+	for i, name := range names {
+		if g := data[fmt.Sprintf("r%d", i)]; g != nil {
+			repos[name] = g.repository()
+			if p := g.readmePath(); p != "" {
+				paths[name] = p
+			}
+		}
+	}
+	return paths, nil
+}
+
+// getReadmes() requests the readmes at the given paths of a single batch of
+// repositories, with one aliased repository field per readme, and adds them
+// to the repositories in repos.
+func (c *Client) getReadmes(names []string, paths map[string]string, repos map[string]*Repository) error {
+	var query strings.Builder
+	params := []string{}
+	variables := map[string]string{}
+	for i, name := range names {
+		p, ok := paths[name]
+		if !ok {
+			continue
+		}
+		parts := strings.Split(name, "/")
+		params = append(params, fmt.Sprintf("$o%d: String!, $n%d: String!, $e%d: String!", i, i, i))
+		variables[fmt.Sprintf("o%d", i)] = parts[0]
+		variables[fmt.Sprintf("n%d", i)] = parts[1]
+		variables[fmt.Sprintf("e%d", i)] = "HEAD:" + p
+		fmt.Fprintf(&query, "  r%d: repository(owner: $o%d, name: $n%d) { readme: object(expression: $e%d) { ... on Blob { text } } }\n", i, i, i, i)
+	}
+	if len(params) == 0 {
+		return nil
+	}
+
+	data := map[string]*struct {
+		Readme *graphqlBlob
+	}{}
+	err := c.graphql(fmt.Sprintf("query(%s) {\n%s}\n", strings.Join(params, ", "), query.String()), variables, &data)
+	if err != nil {
+		return err
+	}
+
+	for i, name := range names {
+		if g := data[fmt.Sprintf("r%d", i)]; g != nil && g.Readme != nil {
+			repos[name].Readme = &Readme{Path: paths[name], Text: g.Readme.Text}
+		}
+	}
+	return nil
+}
+
+// graphql() sends the GraphQL query with the given variables and decodes the
+// data of the response into data.
+// Missing repositories are reported as NOT_FOUND errors, with the data of
+// the other repositories still present, so they are not treated as errors.
+func (c *Client) graphql(query string, variables map[string]string, data interface{}) error {
+	in := map[string]interface{}{
+		"query":     query,
+		"variables": variables,
+	}
+	out := struct {
+		Data   json.RawMessage
+		Errors []graphqlError
+	}{}
+	err := c.request("POST", c.API+"/graphql", in, &out, http.StatusOK)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range out.Errors {
+		if e.Type != "NOT_FOUND" {
+			return fmt.Errorf("GraphQL query failed with error: %s", e.Message)
+		}
+	}
+	if len(out.Data) == 0 || string(out.Data) == "null" {
+		return fmt.Errorf("GraphQL query returned no data")
+	}
+	return json.Unmarshal(out.Data, data)
+}
@@ -0,0 +1,154 @@
+package github
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestClient_GetRepositories(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	server.AddRepository("acme/rocket", map[string]interface{}{
+		"full_name":         "acme/rocket",
+		"homepage":          "https://rocket.example.com",
+		"topics":            []string{"go", "deployment"},
+		"license":           map[string]string{"key": "mit", "name": "MIT License", "spdx_id": "MIT"},
+		"stargazers_count":  100,
+		"forks_count":       12,
+		"open_issues_count": 3,
+		"default_branch":    "main",
+		"created_at":        "2018-01-02T03:04:05Z",
+		"pushed_at":         "2018-02-08T10:00:00Z",
+		"archived":          true,
+	})
+	server.AddReadme("acme/rocket", "readme.md", "# Rocket\n![screenshot](docs/screenshot.png)")
+	server.AddRepository("acme/bare", map[string]interface{}{"full_name": "acme/bare"})
+	c := newTestClient(t, server)
+
+	repos, err := c.GetRepositories([]string{"acme/rocket", "acme/bare", "acme/missing"})
+	if err != nil {
+		t.Fatalf("GetRepositories() failed with error: %v", err)
+	}
+	if len(repos) != 2 {
+		t.Fatalf("GetRepositories() returned %d repositories, want 2", len(repos))
+	}
+
+	repo := repos["acme/rocket"]
+	if repo.FullName != "acme/rocket" || repo.Homepage != "https://rocket.example.com" || len(repo.Topics) != 2 ||
+		repo.Stars != 100 || repo.Forks != 12 || repo.OpenIssues != 3 || !repo.Archived || repo.DefaultBranch != "main" {
+		t.Errorf("GetRepositories() = %+v", repo)
+	}
+	if repo.License == nil || repo.License.SPDXID != "MIT" {
+		t.Errorf("GetRepositories() license = %+v, want MIT", repo.License)
+	}
+	if repo.CreatedAt.Format("2006-01-02") != "2018-01-02" || repo.PushedAt.Format("2006-01-02") != "2018-02-08" {
+		t.Errorf("GetRepositories() created at %s, pushed at %s", repo.CreatedAt, repo.PushedAt)
+	}
+	if repo.Readme == nil || repo.Readme.Path != "readme.md" || repo.Readme.Text == "" {
+		t.Errorf("GetRepositories() readme = %+v, want readme.md", repo.Readme)
+	}
+
+	bare := repos["acme/bare"]
+	if bare.License != nil || bare.Readme != nil || bare.DefaultBranch != "master" {
+		t.Errorf("GetRepositories() = %+v, want no license and no readme", bare)
+	}
+}
+
+func TestClient_GetRepositories_Batches(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	names := []string{}
+	for i := 0; i < GraphQLBatchSize+1; i++ {
+		name := fmt.Sprintf("acme/repo%d", i)
+		server.AddRepository(name, map[string]interface{}{"full_name": name})
+		names = append(names, name)
+	}
+	c := newTestClient(t, server)
+
+	repos, err := c.GetRepositories(names)
+	if err != nil {
+		t.Fatalf("GetRepositories() failed with error: %v", err)
+	}
+	if len(repos) != len(names) {
+		t.Errorf("GetRepositories() returned %d repositories, want %d", len(repos), len(names))
+	}
+
+	queries := 0
+	for _, req := range server.Requests {
+		if req == "POST /graphql" {
+			queries++
+		}
+	}
+	if queries != 2 {
+		t.Errorf("GetRepositories() sent %d queries, want 2", queries)
+	}
+}
+
+func TestClient_GetRepositories_Unauthorized(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	server.AddRepository("acme/rocket", map[string]interface{}{"full_name": "acme/rocket"})
+	c := newTestClient(t, server)
+	c.Tokens = nil
+
+	_, err := c.GetRepositories([]string{"acme/rocket"})
+	if err == nil {
+		t.Errorf("GetRepositories() should have failed without a token")
+	}
+}
+
+func TestClient_GetRepositories_Readmes(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	readmes := map[string][]string{
+		"acme/markdown": {"README.rst", "Readme.markdown"},
+		"acme/github":   {".github/README.md", "README.md"},
+		"acme/docs":     {"docs/readme", "docs/guide.md"},
+		"acme/other":    {"READMEFIRST.md", "src/README.md"},
+	}
+	for name, paths := range readmes {
+		server.AddRepository(name, map[string]interface{}{"full_name": name})
+		for _, path := range paths {
+			server.AddReadme(name, path, "# "+path)
+		}
+	}
+	c := newTestClient(t, server)
+
+	repos, err := c.GetRepositories([]string{"acme/markdown", "acme/github", "acme/docs", "acme/other"})
+	if err != nil {
+		t.Fatalf("GetRepositories() failed with error: %v", err)
+	}
+
+	want := map[string]string{
+		"acme/markdown": "Readme.markdown",
+		"acme/github":   ".github/README.md",
+		"acme/docs":     "docs/readme",
+		"acme/other":    "",
+	}
+	for name, path := range want {
+		readme := repos[name].Readme
+		if path == "" {
+			if readme != nil {
+				t.Errorf("GetRepositories() readme of %s = %+v, want none", name, readme)
+			}
+			continue
+		}
+		if readme == nil || readme.Path != path || readme.Text != "# "+path {
+			t.Errorf("GetRepositories() readme of %s = %+v, want %s", name, readme, path)
+		}
+	}
+
+	queries := 0
+	for _, req := range server.Requests {
+		if req == "POST /graphql" {
+			queries++
+		}
+	}
+	if queries != 2 {
+		t.Errorf("GetRepositories() sent %d queries, want 2", queries)
+	}
+}
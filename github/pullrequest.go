@@ -0,0 +1,201 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PullRequest is the part of the Github pull request object used when opening
+// and merging pull requests.
+type PullRequest struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+	Head    struct {
+		Ref string `json:"ref"`
+	} `json:"head"`
+}
+
+// PullRequestOptions configures the pull request opened by OpenPullRequest.
+type PullRequestOptions struct {
+	// Head is the branch the files are committed to, created if missing.
+	Head string
+	// From is the branch Head is created from, the configured branch if empty
+	// (eg. the head of another pull request that is still open).
+	From string
+	// Title of the pull request. The first line of the commit message is used if empty.
+	Title string
+	// Body of the pull request, in Markdown.
+	Body   string
+	Labels []string
+	// AutoMerge merges the pull request right after opening it, using MergeMethod
+	// (merge, squash or rebase).
+	AutoMerge   bool
+	MergeMethod string
+}
+
+// CreateBranch creates a branch pointing to the given commit. An existing branch
+// with the same name (reported by Github with 422 "Reference already exists") is
+// reset to that commit, so that a run can be repeated on the same day without
+// building on a stale branch. Other errors are returned as they are.
+func (c *Client) CreateBranch(branch string, sha string) error {
+	// POST /repos/:owner/:repo/git/refs
+	params := map[string]string{
+		"ref": "refs/heads/" + branch,
+		"sha": sha,
+	}
+	err := c.request("POST", c.repoURL()+"/git/refs", params, nil, http.StatusCreated)
+	if err == nil || !referenceExists(err) {
+		return err
+	}
+
+	head, err := c.BranchHead(branch)
+	if err != nil {
+		return err
+	}
+	if head == sha {
+		c.logger().Info("Branch already exists, reusing it", "branch", branch)
+		return nil
+	}
+
+	// PATCH /repos/:owner/:repo/git/refs/heads/:branch
+	refParams := struct {
+		SHA   string `json:"sha"`
+		Force bool   `json:"force"`
+	}{sha, true}
+	err = c.request("PATCH", c.repoURL()+"/git/refs/heads/"+branch, refParams, nil, http.StatusOK)
+	if err != nil {
+		return fmt.Errorf("resetting existing branch %s failed: %v", branch, err)
+	}
+	c.logger().Info("Branch already exists, reset it to the base commit", "branch", branch, "sha", sha)
+	return nil
+}
+
+// referenceExists() reports whether err is the reply of Github to creating a
+// reference that already exists.
+func referenceExists(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.Status == http.StatusUnprocessableEntity &&
+		strings.Contains(statusErr.Message, "Reference already exists")
+}
+
+// FindPullRequest returns the open pull request from head to base, or nil
+// if there is none.
+func (c *Client) FindPullRequest(head string, base string) (*PullRequest, error) {
+	// GET /repos/:owner/:repo/pulls?head=:owner::head&base=:base&state=open
+	q := url.Values{}
+	q.Set("state", "open")
+	q.Set("head", c.Owner+":"+head)
+	q.Set("base", base)
+	u := c.repoURL() + "/pulls?" + q.Encode()
+	pulls := []PullRequest{}
+	err := c.request("GET", u, nil, &pulls, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	if len(pulls) == 0 {
+		return nil, nil
+	}
+	return &pulls[0], nil
+}
+
+// OpenPullRequests returns the open pull requests to base, up to 100 of them,
+// most recently created first.
+func (c *Client) OpenPullRequests(base string) ([]PullRequest, error) {
+	// GET /repos/:owner/:repo/pulls?state=open&base=:base&per_page=100
+	q := url.Values{}
+	q.Set("state", "open")
+	q.Set("base", base)
+	q.Set("per_page", "100")
+	pulls := []PullRequest{}
+	err := c.request("GET", c.repoURL()+"/pulls?"+q.Encode(), nil, &pulls, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return pulls, nil
+}
+
+// OpenPullRequest commits the files to the head branch, created from the
+// configured branch (or opts.From), and opens a pull request back to the
+// configured branch.
+// The pull request is labeled with the given labels and, if auto merge is
+// enabled, merged right away.
+func (c *Client) OpenPullRequest(files []File, message string, opts PullRequestOptions) (*PullRequest, error) {
+	base, err := c.TargetBranch()
+	if err != nil {
+		return nil, err
+	}
+	head := opts.Head
+	from := opts.From
+	if from == "" {
+		from = base
+	}
+
+	// 1. Create the branch and commit the files to it
+	sha, err := c.BranchHead(from)
+	if err != nil {
+		return nil, err
+	}
+	err = c.CreateBranch(head, sha)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.Commit(head, files, message)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Open the pull request, or reuse the one opened by a previous run
+	pull, err := c.FindPullRequest(head, base)
+	if err != nil {
+		return nil, err
+	}
+	if pull == nil {
+		title := opts.Title
+		if title == "" {
+			title = strings.SplitN(message, "\n", 2)[0]
+		}
+
+		// POST /repos/:owner/:repo/pulls
+		params := map[string]string{
+			"title": title,
+			"head":  head,
+			"base":  base,
+			"body":  opts.Body,
+		}
+		pull = &PullRequest{}
+		err = c.request("POST", c.repoURL()+"/pulls", params, pull, http.StatusCreated)
+		if err != nil {
+			return nil, err
+		}
+		c.logger().Info("Opened pull request", "url", pull.HTMLURL)
+	}
+
+	// 3. Label the pull request
+	if len(opts.Labels) > 0 {
+		// POST /repos/:owner/:repo/issues/:number/labels
+		u := fmt.Sprintf("%s/issues/%d/labels", c.repoURL(), pull.Number)
+		params := map[string][]string{"labels": opts.Labels}
+		err = c.request("POST", u, params, nil, http.StatusOK)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 4. Merge the pull request
+	if opts.AutoMerge {
+		// PUT /repos/:owner/:repo/pulls/:number/merge
+		u := fmt.Sprintf("%s/pulls/%d/merge", c.repoURL(), pull.Number)
+		params := map[string]string{"merge_method": opts.MergeMethod}
+		err = c.request("PUT", u, params, nil, http.StatusOK)
+		if err != nil {
+			return nil, fmt.Errorf("merging pull request %s failed: %v", pull.HTMLURL, err)
+		}
+		c.logger().Info("Merged pull request", "url", pull.HTMLURL)
+	}
+
+	return pull, nil
+}
@@ -0,0 +1,179 @@
+package github
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestClient_OpenPullRequest(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	c := newTestClient(t, server)
+
+	opts := PullRequestOptions{
+		Head:   "trending/2018-02-08",
+		Body:   "Body",
+		Labels: []string{"trending", "automated"},
+	}
+	files := []File{{Path: "2018-02-08.json", Content: []byte("{}")}}
+
+	// Opening twice should reuse the branch and pull request
+	var numbers []int
+	for i := 0; i < 2; i++ {
+		pull, err := c.OpenPullRequest(files, "Title\n\nDetails", opts)
+		if err != nil {
+			t.Fatalf("OpenPullRequest() failed with error: %v", err)
+		}
+		numbers = append(numbers, pull.Number)
+	}
+	if numbers[0] != numbers[1] {
+		t.Errorf("OpenPullRequest() opened pull requests %v, want the first one reused", numbers)
+	}
+
+	pulls := server.Pulls()
+	if len(pulls) != 1 {
+		t.Fatalf("Opened %d pull requests, want %d", len(pulls), 1)
+	}
+	pull := pulls[0]
+	if pull.Head != opts.Head || pull.Base != "master" {
+		t.Errorf("Pull request from %s to %s, want from %s to master", pull.Head, pull.Base, opts.Head)
+	}
+	if pull.Title != "Title" || pull.Body != "Body" {
+		t.Errorf("Pull request has title %q and body %q", pull.Title, pull.Body)
+	}
+	if strings.Join(pull.Labels, ",") != "trending,automated" {
+		t.Errorf("Pull request has labels %v", pull.Labels)
+	}
+	if pull.Merged {
+		t.Errorf("Pull request should not have been merged")
+	}
+	if _, ok := server.File("master", files[0].Path); ok {
+		t.Errorf("%s should not be committed to the default branch", files[0].Path)
+	}
+}
+
+func TestClient_OpenPullRequest_AutoMerge(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	c := newTestClient(t, server)
+
+	opts := PullRequestOptions{Head: "daily", AutoMerge: true, MergeMethod: "squash"}
+	files := []File{{Path: "a.json", Content: []byte("A")}}
+	_, err := c.OpenPullRequest(files, "Message", opts)
+	if err != nil {
+		t.Fatalf("OpenPullRequest() failed with error: %v", err)
+	}
+
+	pulls := server.Pulls()
+	if len(pulls) != 1 || !pulls[0].Merged {
+		t.Fatalf("Pull request was not opened and merged")
+	}
+	if _, ok := server.File("master", "a.json"); !ok {
+		t.Errorf("a.json was not merged into the default branch")
+	}
+}
+
+func TestClient_OpenPullRequest_From(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	c := newTestClient(t, server)
+
+	// The second pull request is stacked on the first one, which is still open
+	_, err := c.OpenPullRequest([]File{{Path: "a.json", Content: []byte("A")}}, "A", PullRequestOptions{Head: "day-a"})
+	if err != nil {
+		t.Fatalf("OpenPullRequest() failed with error: %v", err)
+	}
+	_, err = c.OpenPullRequest([]File{{Path: "b.json", Content: []byte("B")}}, "B", PullRequestOptions{Head: "day-b", From: "day-a"})
+	if err != nil {
+		t.Fatalf("OpenPullRequest() failed with error: %v", err)
+	}
+	if _, ok := server.File("day-b", "a.json"); !ok {
+		t.Errorf("a.json is missing from branch day-b, although it was created from day-a")
+	}
+
+	pulls, err := c.OpenPullRequests("master")
+	if err != nil {
+		t.Fatalf("OpenPullRequests() failed with error: %v", err)
+	}
+	var heads []string
+	for _, pull := range pulls {
+		heads = append(heads, pull.Head.Ref)
+	}
+	sort.Strings(heads)
+	if strings.Join(heads, ",") != "day-a,day-b" {
+		t.Errorf("OpenPullRequests() = %v, want day-a,day-b", heads)
+	}
+}
+
+func TestClient_OpenPullRequest_EscapedBranches(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	server.CreateBranch("release/2018+1")
+	c := newTestClient(t, server)
+	c.Branch = "release/2018+1"
+
+	opts := PullRequestOptions{Head: "trending/2018-02-08+1"}
+	files := []File{{Path: "2018-02-08.json", Content: []byte("{}")}}
+	for i := 0; i < 2; i++ {
+		_, err := c.OpenPullRequest(files, "Title", opts)
+		if err != nil {
+			t.Fatalf("OpenPullRequest() failed with error: %v", err)
+		}
+	}
+
+	if pulls := server.Pulls(); len(pulls) != 1 {
+		t.Errorf("Opened %d pull requests, want the first one found and reused", len(pulls))
+	}
+}
+
+func TestClient_CreateBranch_ResetsStaleBranch(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	server.CreateBranch("trending/2018-02-08")
+	c := newTestClient(t, server)
+
+	// Advance the default branch, leaving the existing branch behind
+	err := c.Commit("master", []File{{Path: "a.json", Content: []byte("A")}}, "Advance")
+	if err != nil {
+		t.Fatalf("Commit() failed with error: %v", err)
+	}
+	sha, err := c.BranchHead("master")
+	if err != nil {
+		t.Fatalf("BranchHead() failed with error: %v", err)
+	}
+
+	err = c.CreateBranch("trending/2018-02-08", sha)
+	if err != nil {
+		t.Fatalf("CreateBranch() failed with error: %v", err)
+	}
+	got, err := c.BranchHead("trending/2018-02-08")
+	if err != nil {
+		t.Fatalf("BranchHead() failed with error: %v", err)
+	}
+	if got != sha {
+		t.Errorf("Existing branch points to %s, want it reset to %s", got, sha)
+	}
+}
+
+func TestClient_CreateBranch_Fail(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	server.CreateBranch("trending/2018-02-08")
+	c := newTestClient(t, server)
+	server.FailWrites = http.StatusForbidden
+
+	err := c.CreateBranch("trending/2018-02-08", "0000000000000000000000000000000000000000")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.Method != "POST" || statusErr.Status != http.StatusForbidden {
+		t.Errorf("CreateBranch() error = %v, want the 403 of creating the branch", err)
+	}
+}
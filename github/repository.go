@@ -0,0 +1,61 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Repository is the metadata of a Github repository, as returned by the
+// repositories API (https://developer.github.com/v3/repos/#get).
+type Repository struct {
+	FullName      string    `json:"full_name"`
+	Description   string    `json:"description"`
+	Homepage      string    `json:"homepage"`
+	Topics        []string  `json:"topics"`
+	License       *License  `json:"license"`
+	Stars         int       `json:"stargazers_count"`
+	Forks         int       `json:"forks_count"`
+	OpenIssues    int       `json:"open_issues_count"`
+	DefaultBranch string    `json:"default_branch"`
+	Archived      bool      `json:"archived"`
+	CreatedAt     time.Time `json:"created_at"`
+	// PushedAt is the time of the last push to any branch.
+	PushedAt time.Time `json:"pushed_at"`
+	// Readme is the readme file on the default branch. It is only populated by
+	// GetRepositories, and is nil if no readme was found.
+	Readme *Readme `json:"-"`
+}
+
+// Readme is the Markdown source of a readme file.
+type Readme struct {
+	Path string
+	Text string
+}
+
+// License is the license of a repository, as detected by Github.
+type License struct {
+	Key    string `json:"key"`
+	Name   string `json:"name"`
+	SPDXID string `json:"spdx_id"`
+}
+
+// GetRepository returns the metadata of the repository owner/name, which does
+// not have to be the repository of the client.
+func (c *Client) GetRepository(owner string, name string) (*Repository, error) {
+	// GET /repos/:owner/:repo
+	u := fmt.Sprintf("%s/repos/%s/%s", c.API, owner, name)
+	r, err := c.newRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Topics are only included with the mercy preview media type
+	r.Header.Set("Accept", "application/vnd.github.mercy-preview+json")
+
+	repo := &Repository{}
+	err = c.do(r, repo, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
@@ -0,0 +1,42 @@
+package github
+
+import (
+	"testing"
+)
+
+func TestClient_GetRepository(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	server.AddRepository("acme/rocket", map[string]interface{}{
+		"full_name":         "acme/rocket",
+		"homepage":          "https://rocket.example.com",
+		"topics":            []string{"go", "deployment"},
+		"license":           map[string]string{"key": "mit", "name": "MIT License", "spdx_id": "MIT"},
+		"forks_count":       12,
+		"open_issues_count": 3,
+		"created_at":        "2018-01-02T03:04:05Z",
+		"pushed_at":         "2018-02-08T10:00:00Z",
+		"archived":          true,
+	})
+	c := newTestClient(t, server)
+
+	repo, err := c.GetRepository("acme", "rocket")
+	if err != nil {
+		t.Fatalf("GetRepository() failed with error: %v", err)
+	}
+	if repo.Homepage != "https://rocket.example.com" || len(repo.Topics) != 2 || repo.Forks != 12 || repo.OpenIssues != 3 || !repo.Archived {
+		t.Errorf("GetRepository() = %+v", repo)
+	}
+	if repo.License == nil || repo.License.SPDXID != "MIT" {
+		t.Errorf("GetRepository() license = %+v, want MIT", repo.License)
+	}
+	if repo.CreatedAt.Format("2006-01-02") != "2018-01-02" || repo.PushedAt.Format("2006-01-02") != "2018-02-08" {
+		t.Errorf("GetRepository() created at %s, pushed at %s", repo.CreatedAt, repo.PushedAt)
+	}
+
+	_, err = c.GetRepository("acme", "missing")
+	if err == nil {
+		t.Errorf("GetRepository() should have failed for a missing repository")
+	}
+}
@@ -0,0 +1,17 @@
+module github.com/quasoft/changelog-nightly-parser
+
+go 1.21
+
+require (
+	github.com/antchfx/htmlquery v1.3.4
+	github.com/aws/aws-lambda-go v1.47.0
+	go.etcd.io/bbolt v1.3.10
+	golang.org/x/net v0.33.0
+)
+
+require (
+	github.com/antchfx/xpath v1.3.3 // indirect
+	github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+)
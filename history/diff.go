@@ -0,0 +1,151 @@
+package history
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+// RepoChange describes a repository listed in a category on the previous day,
+// the current day or both. Ranks start from 1 and are 0 if the repository was
+// not listed on that day.
+type RepoChange struct {
+	Name         string `json:"Name"`
+	URL          string `json:"URL"`
+	Rank         int    `json:"Rank"`
+	PreviousRank int    `json:"PreviousRank"`
+	Stars        int    `json:"Stars"`
+	// StarDelta is the number of stars gained since the previous day.
+	StarDelta int `json:"StarDelta"`
+}
+
+// Movement returns the number of ranks the repository moved up (or down, if negative).
+func (c *RepoChange) Movement() int {
+	return c.PreviousRank - c.Rank
+}
+
+// CategoryDiff is the difference between the repositories of a category on two days.
+type CategoryDiff struct {
+	Category string `json:"Category"`
+	// Added are the repositories that were not listed in the category on the previous day.
+	Added []RepoChange `json:"Added"`
+	// Dropped are the repositories that are not listed in the category anymore.
+	Dropped []RepoChange `json:"Dropped"`
+	// Kept are the repositories listed in the category on both days.
+	Kept []RepoChange `json:"Kept"`
+}
+
+// DayDiff is the difference between the trending repositories of two days.
+type DayDiff struct {
+	Date         string         `json:"Date"`
+	PreviousDate string         `json:"PreviousDate"`
+	Categories   []CategoryDiff `json:"Categories"`
+}
+
+// Diff compares the trending repositories of the current day with those of the
+// previous day, category by category. Repositories are identified by their
+// case-insensitive name.
+func Diff(previous Day, current Day) *DayDiff {
+	d := &DayDiff{
+		Date:         current.Date.Format("2006-01-02"),
+		PreviousDate: previous.Date.Format("2006-01-02"),
+		Categories:   []CategoryDiff{},
+	}
+
+	prev := [][]nightly.Repository{previous.Trending.First, previous.Trending.New, previous.Trending.Repeaters}
+	cur := [][]nightly.Repository{current.Trending.First, current.Trending.New, current.Trending.Repeaters}
+	for i, name := range []string{FirstTimers, TopNew, RepeatPerformers} {
+		d.Categories = append(d.Categories, diffCategory(name, prev[i], cur[i]))
+	}
+	return d
+}
+
+// diffCategory() compares the repositories of a category on two days.
+func diffCategory(category string, previous []nightly.Repository, current []nightly.Repository) CategoryDiff {
+	cd := CategoryDiff{Category: category, Added: []RepoChange{}, Dropped: []RepoChange{}, Kept: []RepoChange{}}
+
+	prevRank := map[string]int{}
+	for i, r := range previous {
+		prevRank[strings.ToLower(r.Name)] = i + 1
+	}
+	curRank := map[string]int{}
+	for i, r := range current {
+		curRank[strings.ToLower(r.Name)] = i + 1
+	}
+
+	for i, r := range current {
+		c := RepoChange{Name: r.Name, URL: r.URL, Rank: i + 1, Stars: r.Stars}
+		pr, ok := prevRank[strings.ToLower(r.Name)]
+		if !ok {
+			cd.Added = append(cd.Added, c)
+			continue
+		}
+		c.PreviousRank = pr
+		c.StarDelta = r.Stars - previous[pr-1].Stars
+		cd.Kept = append(cd.Kept, c)
+	}
+	for i, r := range previous {
+		if _, ok := curRank[strings.ToLower(r.Name)]; !ok {
+			cd.Dropped = append(cd.Dropped, RepoChange{Name: r.Name, URL: r.URL, PreviousRank: i + 1, Stars: r.Stars})
+		}
+	}
+	return cd
+}
+
+// categoryTitles are the titles of the categories on the nightly page.
+var categoryTitles = map[string]string{
+	FirstTimers:      "First timers",
+	TopNew:           "Top new",
+	RepeatPerformers: "Repeat performers",
+}
+
+// Markdown returns the difference as a Markdown section, suitable for commit
+// messages and pull request bodies. Categories without changes are omitted.
+func (d *DayDiff) Markdown() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "### Changes since %s\n", d.PreviousDate)
+
+	changed := false
+	for _, cd := range d.Categories {
+		moved := []RepoChange{}
+		for _, c := range cd.Kept {
+			if c.Movement() != 0 || c.StarDelta != 0 {
+				moved = append(moved, c)
+			}
+		}
+		if len(cd.Added) == 0 && len(cd.Dropped) == 0 && len(moved) == 0 {
+			continue
+		}
+		changed = true
+
+		fmt.Fprintf(&buf, "\n**%s**\n\n", categoryTitles[cd.Category])
+		for _, c := range cd.Added {
+			fmt.Fprintf(&buf, "- New: [%s](%s) at #%d\n", c.Name, c.URL, c.Rank)
+		}
+		for _, c := range moved {
+			fmt.Fprintf(&buf, "- %s: [%s](%s) #%d → #%d, %+d stars\n", movementLabel(c.Movement()), c.Name, c.URL, c.PreviousRank, c.Rank, c.StarDelta)
+		}
+		for _, c := range cd.Dropped {
+			fmt.Fprintf(&buf, "- Dropped: [%s](%s), was #%d\n", c.Name, c.URL, c.PreviousRank)
+		}
+	}
+
+	if !changed {
+		fmt.Fprintf(&buf, "\nNo changes.\n")
+	}
+	return buf.String()
+}
+
+// movementLabel() describes a movement of ranks.
+func movementLabel(movement int) string {
+	switch {
+	case movement > 0:
+		return fmt.Sprintf("Up %d", movement)
+	case movement < 0:
+		return fmt.Sprintf("Down %d", -movement)
+	default:
+		return "Same rank"
+	}
+}
@@ -0,0 +1,76 @@
+package history
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	previous := Day{date("2018-02-08"), &nightly.TrendingRepos{
+		Repeaters: []nightly.Repository{
+			{Name: "acme/rocket", URL: "https://github.com/acme/rocket", Stars: 100},
+			{Name: "acme/jet", URL: "https://github.com/acme/jet", Stars: 50},
+			{Name: "jane/notes", URL: "https://github.com/jane/notes", Stars: 20},
+		},
+	}}
+	current := Day{date("2018-02-09"), &nightly.TrendingRepos{
+		First: []nightly.Repository{{Name: "jane/tasks", URL: "https://github.com/jane/tasks", Stars: 5}},
+		Repeaters: []nightly.Repository{
+			{Name: "Acme/Jet", URL: "https://github.com/acme/jet", Stars: 80},
+			{Name: "acme/rocket", URL: "https://github.com/acme/rocket", Stars: 110},
+		},
+	}}
+
+	d := Diff(previous, current)
+	if d.Date != "2018-02-09" || d.PreviousDate != "2018-02-08" || len(d.Categories) != 3 {
+		t.Fatalf("Diff() = %+v", d)
+	}
+
+	first := d.Categories[0]
+	if first.Category != FirstTimers || len(first.Added) != 1 || first.Added[0].Name != "jane/tasks" || first.Added[0].Rank != 1 {
+		t.Errorf("Diff() first timers = %+v", first)
+	}
+
+	repeaters := d.Categories[2]
+	if len(repeaters.Dropped) != 1 || repeaters.Dropped[0].Name != "jane/notes" || repeaters.Dropped[0].PreviousRank != 3 {
+		t.Errorf("Diff() dropped = %+v", repeaters.Dropped)
+	}
+	if len(repeaters.Kept) != 2 {
+		t.Fatalf("Diff() kept = %+v", repeaters.Kept)
+	}
+	jet, rocket := repeaters.Kept[0], repeaters.Kept[1]
+	if jet.Movement() != 1 || jet.StarDelta != 30 || rocket.Movement() != -1 || rocket.StarDelta != 10 {
+		t.Errorf("Diff() kept = %+v", repeaters.Kept)
+	}
+
+	md := d.Markdown()
+	for _, want := range []string{
+		"### Changes since 2018-02-08",
+		"**First timers**",
+		"- New: [jane/tasks](https://github.com/jane/tasks) at #1",
+		"- Up 1: [Acme/Jet](https://github.com/acme/jet) #2 → #1, +30 stars",
+		"- Down 1: [acme/rocket](https://github.com/acme/rocket) #1 → #2, +10 stars",
+		"- Dropped: [jane/notes](https://github.com/jane/notes), was #3",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown() does not contain %q:\n%s", want, md)
+		}
+	}
+	if strings.Contains(md, "Top new") {
+		t.Errorf("Markdown() should omit categories without changes:\n%s", md)
+	}
+}
+
+func TestDiff_NoChanges(t *testing.T) {
+	t.Parallel()
+
+	tr := &nightly.TrendingRepos{First: []nightly.Repository{{Name: "acme/rocket", Stars: 1}}}
+	md := Diff(Day{date("2018-02-08"), tr}, Day{date("2018-02-09"), tr}).Markdown()
+	if !strings.Contains(md, "No changes.") {
+		t.Errorf("Markdown() = %s, want no changes", md)
+	}
+}
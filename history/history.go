@@ -0,0 +1,143 @@
+// Package history loads the daily files published by previous runs and
+// computes how repositories trended over the days.
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+// indexFileName is the name of the index of daily files in the Github repository.
+const indexFileName = "index.json"
+
+// Day is the trending repositories of a single day.
+type Day struct {
+	Date     time.Time
+	Trending *nightly.TrendingRepos
+}
+
+// FileGetter reads files from a repository, eg. *github.Client.
+// GetFile returns nil content and no error if the file does not exist.
+type FileGetter interface {
+	GetFile(path string) ([]byte, string, error)
+}
+
+// LoadDir loads the daily files named YYYY-MM-DD.json in dir (eg. written by
+// the replay command), sorted from the oldest day to the most recent one.
+// Other files are ignored.
+func LoadDir(dir string) ([]Day, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	days := []Day{}
+	for _, name := range names {
+		date, err := time.Parse("2006-01-02", strings.TrimSuffix(filepath.Base(name), ".json"))
+		if err != nil {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		day, err := parseDay(date, content)
+		if err != nil {
+			return nil, fmt.Errorf("Could not load %s: %v", name, err)
+		}
+		days = append(days, day)
+	}
+
+	sortDays(days)
+	return days, nil
+}
+
+// LoadIndexed loads the daily files listed in the index.json file of a repository
+// (eg. the Github repository the daily files are uploaded to), sorted from the
+// oldest day to the most recent one. Returns no days if there is no index.
+func LoadIndexed(files FileGetter) ([]Day, error) {
+	content, _, err := files.GetFile(indexFileName)
+	if err != nil {
+		return nil, err
+	}
+	if len(content) == 0 {
+		return []Day{}, nil
+	}
+
+	// Only the fields needed here, see pipeline.Index for the full structure
+	idx := struct {
+		Days []struct {
+			Date string
+			Path string
+		}
+	}{}
+	err = json.Unmarshal(content, &idx)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse %s: %v", indexFileName, err)
+	}
+
+	days := []Day{}
+	for _, entry := range idx.Days {
+		date, err := time.Parse("2006-01-02", entry.Date)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid date %q in %s", entry.Date, indexFileName)
+		}
+
+		content, _, err := files.GetFile(entry.Path)
+		if err != nil {
+			return nil, err
+		}
+		if content == nil {
+			return nil, fmt.Errorf("Daily file %s listed in %s does not exist", entry.Path, indexFileName)
+		}
+		day, err := parseDay(date, content)
+		if err != nil {
+			return nil, fmt.Errorf("Could not load %s: %v", entry.Path, err)
+		}
+		days = append(days, day)
+	}
+
+	sortDays(days)
+	return days, nil
+}
+
+// parseDay() parses the content of a daily file.
+func parseDay(date time.Time, content []byte) (Day, error) {
+	trending := &nightly.TrendingRepos{}
+	err := json.Unmarshal(content, trending)
+	if err != nil {
+		return Day{}, err
+	}
+	return Day{Date: date, Trending: trending}, nil
+}
+
+// sortDays() sorts the days from the oldest to the most recent one.
+func sortDays(days []Day) {
+	sort.SliceStable(days, func(i, j int) bool {
+		return days[i].Date.Before(days[j].Date)
+	})
+}
+
+// Between returns the days from first to last, inclusive. Zero times leave
+// the range open on that side.
+func Between(days []Day, first time.Time, last time.Time) []Day {
+	filtered := []Day{}
+	for _, day := range days {
+		if !first.IsZero() && day.Date.Before(first) {
+			continue
+		}
+		if !last.IsZero() && day.Date.After(last) {
+			continue
+		}
+		filtered = append(filtered, day)
+	}
+	return filtered
+}
@@ -0,0 +1,124 @@
+package history
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+// date() parses a YYYY-MM-DD date.
+func date(s string) time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return d
+}
+
+// dailyJSON() returns the content of a daily file with the named repositories
+// as first timers.
+func dailyJSON(t *testing.T, names ...string) []byte {
+	tr := nightly.TrendingRepos{First: []nightly.Repository{}}
+	for _, name := range names {
+		tr.First = append(tr.First, nightly.Repository{Name: name})
+	}
+	j, err := json.Marshal(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return j
+}
+
+// stubFiles is a FileGetter serving files from a map.
+type stubFiles map[string][]byte
+
+func (f stubFiles) GetFile(path string) ([]byte, string, error) {
+	return f[path], "", nil
+}
+
+func TestLoadDir(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"2018-02-10.json": dailyJSON(t, "acme/rocket", "acme/jet"),
+		"2018-02-08.json": dailyJSON(t, "acme/rocket"),
+		"index.json":      []byte(`{"Days": []}`),
+		"notes.txt":       []byte("not a daily file"),
+	}
+	for name, content := range files {
+		err := ioutil.WriteFile(filepath.Join(dir, name), content, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	days, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("LoadDir() failed with error: %v", err)
+	}
+	if len(days) != 2 {
+		t.Fatalf("LoadDir() returned %d days, want 2", len(days))
+	}
+	if days[0].Date.Format("2006-01-02") != "2018-02-08" || len(days[1].Trending.First) != 2 {
+		t.Errorf("LoadDir() = %+v, want days sorted from the oldest", days)
+	}
+}
+
+func TestLoadIndexed(t *testing.T) {
+	t.Parallel()
+
+	files := stubFiles{
+		"index.json": []byte(`{"Latest": "2018-02-09.json", "Days": [
+			{"Date": "2018-02-09", "Path": "2018-02-09.json"},
+			{"Date": "2018-02-08", "Path": "daily/2018-02-08.json"}
+		]}`),
+		"2018-02-09.json":       dailyJSON(t, "acme/rocket", "acme/jet"),
+		"daily/2018-02-08.json": dailyJSON(t, "acme/rocket"),
+	}
+
+	days, err := LoadIndexed(files)
+	if err != nil {
+		t.Fatalf("LoadIndexed() failed with error: %v", err)
+	}
+	if len(days) != 2 || days[0].Date.Format("2006-01-02") != "2018-02-08" || len(days[1].Trending.First) != 2 {
+		t.Errorf("LoadIndexed() = %+v", days)
+	}
+
+	days, err = LoadIndexed(stubFiles{})
+	if err != nil || len(days) != 0 {
+		t.Errorf("LoadIndexed() = %v, %v, want no days without an index", days, err)
+	}
+
+	delete(files, "2018-02-09.json")
+	_, err = LoadIndexed(files)
+	if err == nil {
+		t.Errorf("LoadIndexed() should have failed for a missing daily file")
+	}
+}
+
+func TestBetween(t *testing.T) {
+	t.Parallel()
+
+	days := []Day{{Date: date("2018-02-08")}, {Date: date("2018-02-09")}, {Date: date("2018-02-10")}}
+
+	tests := []struct {
+		name  string
+		first time.Time
+		last  time.Time
+		want  int
+	}{
+		{"Open range", time.Time{}, time.Time{}, 3},
+		{"From", date("2018-02-09"), time.Time{}, 2},
+		{"To", time.Time{}, date("2018-02-08"), 1},
+		{"Single day", date("2018-02-09"), date("2018-02-09"), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Between(days, tt.first, tt.last); len(got) != tt.want {
+				t.Errorf("Between() returned %d days, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
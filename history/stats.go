@@ -0,0 +1,157 @@
+package history
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+// Names of the categories, as in the daily files.
+const (
+	FirstTimers      = nightly.FirstTimers
+	TopNew           = nightly.TopNew
+	RepeatPerformers = nightly.RepeatPerformers
+)
+
+// Transition is a change of the category a repository was listed in, between
+// two days on which it was trending.
+type Transition struct {
+	Date string `json:"Date"`
+	From string `json:"From"`
+	To   string `json:"To"`
+}
+
+// RepoStats describes how a repository trended over the days of the history.
+type RepoStats struct {
+	Name      string `json:"Name"`
+	URL       string `json:"URL"`
+	FirstSeen string `json:"FirstSeen"`
+	LastSeen  string `json:"LastSeen"`
+	// DaysTrending is the number of days the repository was listed on.
+	DaysTrending int `json:"DaysTrending"`
+	// CurrentStreak is the number of consecutive days the repository was listed
+	// on, up to the last day of the history, or 0 if it was not listed on that day.
+	CurrentStreak int `json:"CurrentStreak"`
+	LongestStreak int `json:"LongestStreak"`
+	FirstStars    int `json:"FirstStars"`
+	LastStars     int `json:"LastStars"`
+	// StarVelocity is the average number of stars gained per day between the
+	// first and the last day the repository was listed on.
+	StarVelocity float64 `json:"StarVelocity"`
+	// Categories is the number of days the repository was listed in each category.
+	// A repository listed in several categories on the same day is counted in the
+	// first one only, as for transitions and streaks, so the counts add up to
+	// DaysTrending.
+	Categories  map[string]int `json:"Categories"`
+	Transitions []Transition   `json:"Transitions"`
+}
+
+// Stats is the result of analyzing the history.
+type Stats struct {
+	From         string      `json:"From"`
+	To           string      `json:"To"`
+	Days         int         `json:"Days"`
+	Repositories []RepoStats `json:"Repositories"`
+}
+
+// repoState is the state of a repository while analyzing the days.
+type repoState struct {
+	stats     *RepoStats
+	firstDate time.Time
+	lastDate  time.Time
+	category  string
+	streak    int
+}
+
+// Analyze computes the statistics of every repository in the days, which
+// must be sorted from the oldest day to the most recent one, as returned by
+// LoadDir and LoadIndexed. Repositories are identified by their case-insensitive
+// name. A repository listed in several categories on the same day is considered
+// to be in the first of them, in the order of the nightly page.
+// Repositories are sorted by the number of days trending, then by name.
+func Analyze(days []Day) *Stats {
+	s := &Stats{Days: len(days), Repositories: []RepoStats{}}
+	if len(days) == 0 {
+		return s
+	}
+	s.From = days[0].Date.Format("2006-01-02")
+	s.To = days[len(days)-1].Date.Format("2006-01-02")
+
+	states := map[string]*repoState{}
+	for _, day := range days {
+		date := day.Date.Format("2006-01-02")
+		categories := []struct {
+			name  string
+			repos []nightly.Repository
+		}{
+			{FirstTimers, day.Trending.First},
+			{TopNew, day.Trending.New},
+			{RepeatPerformers, day.Trending.Repeaters},
+		}
+
+		seen := map[string]bool{}
+		for _, cat := range categories {
+			for _, r := range cat.repos {
+				key := strings.ToLower(r.Name)
+				st, ok := states[key]
+				if !ok {
+					st = &repoState{firstDate: day.Date, stats: &RepoStats{
+						Name:        r.Name,
+						URL:         r.URL,
+						FirstSeen:   date,
+						FirstStars:  r.Stars,
+						Categories:  map[string]int{},
+						Transitions: []Transition{},
+					}}
+					states[key] = st
+				}
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				st.stats.Categories[cat.name]++
+
+				if st.category != "" && st.category != cat.name {
+					st.stats.Transitions = append(st.stats.Transitions, Transition{Date: date, From: st.category, To: cat.name})
+				}
+				st.category = cat.name
+
+				if !st.lastDate.IsZero() && st.lastDate.AddDate(0, 0, 1).Equal(day.Date) {
+					st.streak++
+				} else {
+					st.streak = 1
+				}
+				if st.streak > st.stats.LongestStreak {
+					st.stats.LongestStreak = st.streak
+				}
+				st.lastDate = day.Date
+
+				st.stats.DaysTrending++
+				st.stats.LastSeen = date
+				st.stats.LastStars = r.Stars
+			}
+		}
+	}
+
+	last := days[len(days)-1].Date
+	for _, st := range states {
+		if st.lastDate.Equal(last) {
+			st.stats.CurrentStreak = st.streak
+		}
+		if elapsed := st.lastDate.Sub(st.firstDate).Hours() / 24; elapsed > 0 {
+			st.stats.StarVelocity = float64(st.stats.LastStars-st.stats.FirstStars) / elapsed
+		}
+		s.Repositories = append(s.Repositories, *st.stats)
+	}
+
+	sort.Slice(s.Repositories, func(i, j int) bool {
+		a, b := s.Repositories[i], s.Repositories[j]
+		if a.DaysTrending != b.DaysTrending {
+			return a.DaysTrending > b.DaysTrending
+		}
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	})
+	return s
+}
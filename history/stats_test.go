@@ -0,0 +1,75 @@
+package history
+
+import (
+	"testing"
+
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+func TestAnalyze(t *testing.T) {
+	t.Parallel()
+
+	days := []Day{
+		{date("2018-02-06"), &nightly.TrendingRepos{
+			First: []nightly.Repository{{Name: "acme/rocket", Stars: 100}, {Name: "acme/jet", Stars: 10}},
+		}},
+		{date("2018-02-07"), &nightly.TrendingRepos{
+			Repeaters: []nightly.Repository{{Name: "Acme/Rocket", Stars: 300}},
+		}},
+		// acme/jet is missing on 2018-02-08, which breaks its streak
+		{date("2018-02-08"), &nightly.TrendingRepos{
+			New:       []nightly.Repository{{Name: "acme/rocket", Stars: 400}},
+			Repeaters: []nightly.Repository{{Name: "acme/rocket", Stars: 400}},
+		}},
+		{date("2018-02-09"), &nightly.TrendingRepos{
+			Repeaters: []nightly.Repository{{Name: "acme/rocket", Stars: 700}, {Name: "acme/jet", Stars: 40}},
+		}},
+	}
+
+	s := Analyze(days)
+	if s.From != "2018-02-06" || s.To != "2018-02-09" || s.Days != 4 || len(s.Repositories) != 2 {
+		t.Fatalf("Analyze() = %+v", s)
+	}
+
+	rocket := s.Repositories[0]
+	if rocket.Name != "acme/rocket" || rocket.FirstSeen != "2018-02-06" || rocket.LastSeen != "2018-02-09" {
+		t.Errorf("Analyze() rocket = %+v", rocket)
+	}
+	if rocket.DaysTrending != 4 || rocket.CurrentStreak != 4 || rocket.LongestStreak != 4 {
+		t.Errorf("Analyze() rocket trended %d days, streaks %d/%d, want 4/4/4", rocket.DaysTrending, rocket.CurrentStreak, rocket.LongestStreak)
+	}
+	if rocket.FirstStars != 100 || rocket.LastStars != 700 || rocket.StarVelocity != 200 {
+		t.Errorf("Analyze() rocket stars %d -> %d, velocity %v, want 100 -> 700, 200", rocket.FirstStars, rocket.LastStars, rocket.StarVelocity)
+	}
+	// On 2018-02-08 rocket is only counted as top new, the first of its categories
+	if rocket.Categories[RepeatPerformers] != 2 || rocket.Categories[TopNew] != 1 || rocket.Categories[FirstTimers] != 1 {
+		t.Errorf("Analyze() rocket categories = %v", rocket.Categories)
+	}
+	wantTransitions := []Transition{
+		{"2018-02-07", FirstTimers, RepeatPerformers},
+		{"2018-02-08", RepeatPerformers, TopNew},
+		{"2018-02-09", TopNew, RepeatPerformers},
+	}
+	if len(rocket.Transitions) != len(wantTransitions) {
+		t.Fatalf("Analyze() rocket transitions = %+v, want %+v", rocket.Transitions, wantTransitions)
+	}
+	for i := range wantTransitions {
+		if rocket.Transitions[i] != wantTransitions[i] {
+			t.Errorf("Analyze() rocket transition %d = %+v, want %+v", i, rocket.Transitions[i], wantTransitions[i])
+		}
+	}
+
+	jet := s.Repositories[1]
+	if jet.DaysTrending != 2 || jet.CurrentStreak != 1 || jet.LongestStreak != 1 || jet.StarVelocity != 10 {
+		t.Errorf("Analyze() jet = %+v", jet)
+	}
+}
+
+func TestAnalyze_Empty(t *testing.T) {
+	t.Parallel()
+
+	s := Analyze(nil)
+	if s.Days != 0 || s.Repositories == nil || len(s.Repositories) != 0 {
+		t.Errorf("Analyze() = %+v, want no repositories", s)
+	}
+}
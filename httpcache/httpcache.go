@@ -0,0 +1,192 @@
+// Package httpcache provides an http.RoundTripper that caches GET responses in
+// a directory and revalidates them with conditional requests (If-None-Match and
+// If-Modified-Since), reusing the cached body when the server replies with
+// 304 Not Modified.
+//
+// Bodies are stored content-addressed, named by the SHA-256 of their content,
+// so that identical pages fetched from different URLs are stored once. Entries
+// are keyed by the SHA-256 of the URL and the Accept header of the request.
+package httpcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Transport is an http.RoundTripper caching GET responses in Dir.
+type Transport struct {
+	// Dir is the cache directory, created on first use.
+	Dir string
+	// Next sends the requests. http.DefaultTransport is used if nil.
+	Next http.RoundTripper
+}
+
+// New returns a transport caching the responses of next in dir.
+func New(dir string, next http.RoundTripper) *Transport {
+	return &Transport{Dir: dir, Next: next}
+}
+
+// entry is the metadata of a cached response.
+type entry struct {
+	URL          string      `json:"url"`
+	Header       http.Header `json:"header"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+	// Body is the SHA-256 of the body, which is stored in the bodies directory.
+	Body string `json:"body"`
+}
+
+func (t *Transport) next() http.RoundTripper {
+	if t.Next == nil {
+		return http.DefaultTransport
+	}
+	return t.Next
+}
+
+// key() returns the name of the cache entry for the request.
+func key(r *http.Request) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(r.URL.String()+"\n"+r.Header.Get("Accept"))))
+}
+
+func (t *Transport) entryPath(key string) string {
+	return filepath.Join(t.Dir, "entries", key+".json")
+}
+
+func (t *Transport) bodyPath(hash string) string {
+	return filepath.Join(t.Dir, "bodies", hash)
+}
+
+// load() returns the cached entry for the request and its body, or nil if the
+// response is not cached.
+func (t *Transport) load(key string) (*entry, []byte) {
+	j, err := ioutil.ReadFile(t.entryPath(key))
+	if err != nil {
+		return nil, nil
+	}
+	e := entry{}
+	if json.Unmarshal(j, &e) != nil {
+		return nil, nil
+	}
+	body, err := ioutil.ReadFile(t.bodyPath(e.Body))
+	if err != nil {
+		return nil, nil
+	}
+	return &e, body
+}
+
+// store() saves the entry and its body to the cache directory.
+func (t *Transport) store(key string, e *entry, body []byte) error {
+	e.Body = fmt.Sprintf("%x", sha256.Sum256(body))
+	err := writeFile(t.bodyPath(e.Body), body)
+	if err != nil {
+		return err
+	}
+
+	j, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return writeFile(t.entryPath(key), j)
+}
+
+// writeFile() writes the file through a temporary file, so that concurrent
+// readers never see a partially written file.
+func writeFile(name string, content []byte) error {
+	err := os.MkdirAll(filepath.Dir(name), 0755)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(name), ".tmp-")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// cachedResponse() returns a 200 response for the request with the cached header and body.
+func cachedResponse(r *http.Request, e *entry, body []byte) *http.Response {
+	header := e.Header.Clone()
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	header.Set("X-From-Cache", "1")
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       r,
+	}
+}
+
+// RoundTrip sends the request, adding conditional headers if the response is
+// cached. A 304 reply is replaced with the cached response, and 200 replies with
+// an ETag or Last-Modified header are cached. Requests other than GET are sent as-is.
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Method != "GET" {
+		return t.next().RoundTrip(r)
+	}
+
+	k := key(r)
+	cached, cachedBody := t.load(k)
+
+	req := r
+	if cached != nil {
+		req = r.Clone(r.Context())
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := t.next().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		resp.Body.Close()
+		return cachedResponse(r, cached, cachedBody), nil
+	}
+
+	e := &entry{
+		URL:          r.URL.String(),
+		Header:       resp.Header.Clone(),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	if resp.StatusCode != http.StatusOK || (e.ETag == "" && e.LastModified == "") {
+		return resp, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	// A response that can't be cached is still a valid response
+	t.store(k, e, body)
+	return resp, nil
+}
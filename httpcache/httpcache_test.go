@@ -0,0 +1,123 @@
+package httpcache
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// testServer serves a fixed body with the given validator headers and counts the
+// requests that were answered with 304 Not Modified.
+type testServer struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	body        string
+	requests    int
+	notModified int
+}
+
+func newTestServer(t *testing.T, etag string, lastModified string) *testServer {
+	s := &testServer{body: "<html>page</html>"}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.requests++
+		if (etag != "" && r.Header.Get("If-None-Match") == etag) ||
+			(lastModified != "" && r.Header.Get("If-Modified-Since") == lastModified) {
+			s.notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if etag != "" {
+			w.Header().Set("ETag", etag)
+		}
+		if lastModified != "" {
+			w.Header().Set("Last-Modified", lastModified)
+		}
+		w.Write([]byte(s.body))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func get(t *testing.T, client *http.Client, url string) (string, *http.Response) {
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s failed with error: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Reading body failed with error: %v", err)
+	}
+	return string(body), resp
+}
+
+func TestTransport_Revalidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		etag         string
+		lastModified string
+		wantCached   bool
+	}{
+		{"ETag", `"v1"`, "", true},
+		{"Last-Modified", "", "Thu, 08 Feb 2018 00:00:00 GMT", true},
+		{"No validators", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, tt.etag, tt.lastModified)
+			client := &http.Client{Transport: New(t.TempDir(), server.Client().Transport)}
+
+			for i := 0; i < 3; i++ {
+				body, resp := get(t, client, server.URL+"/2018/02/08")
+				if resp.StatusCode != http.StatusOK || body != server.body {
+					t.Fatalf("GET #%d = %d %q, want 200 %q", i+1, resp.StatusCode, body, server.body)
+				}
+				fromCache := resp.Header.Get("X-From-Cache") != ""
+				if fromCache != (tt.wantCached && i > 0) {
+					t.Errorf("GET #%d served from cache = %v", i+1, fromCache)
+				}
+			}
+
+			wantNotModified := 0
+			if tt.wantCached {
+				wantNotModified = 2
+			}
+			if server.requests != 3 || server.notModified != wantNotModified {
+				t.Errorf("Server got %d requests, %d answered with 304, want 3 and %d", server.requests, server.notModified, wantNotModified)
+			}
+		})
+	}
+}
+
+func TestTransport_KeyedByAccept(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, `"v1"`, "")
+	client := &http.Client{Transport: New(t.TempDir(), server.Client().Transport)}
+
+	get(t, client, server.URL)
+
+	r, err := http.NewRequest("GET", server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("Accept", "application/vnd.github.v3.html")
+	resp, err := client.Do(r)
+	if err != nil {
+		t.Fatalf("GET failed with error: %v", err)
+	}
+	resp.Body.Close()
+
+	if server.notModified != 0 {
+		t.Errorf("Request with another Accept header was revalidated against the cached response")
+	}
+}
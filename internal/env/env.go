@@ -0,0 +1,23 @@
+// Package env parses the values of environment variables shared by the
+// configuration of several packages.
+package env
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Bool returns the boolean value of the environment variable read with getenv,
+// or false if the variable is not set.
+func Bool(getenv func(string) string, key string) (bool, error) {
+	v := getenv(key)
+	if v == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("Invalid %s value %q, expected true or false", key, v)
+	}
+	return b, nil
+}
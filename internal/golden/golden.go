@@ -0,0 +1,68 @@
+// Package golden compares test output with golden files kept in testdata
+// directories, and regenerates the golden files when the tests are run with
+// the -update flag:
+//
+//	go test ./nightly ./screenshot ./pipeline -update
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "write the golden files instead of comparing the output with them")
+
+// Assert compares got with the content of the golden file, or writes got to the
+// golden file if the tests are run with -update.
+func Assert(t testing.TB, name string, got []byte) {
+	t.Helper()
+
+	if *update {
+		err := os.MkdirAll(filepath.Dir(name), 0755)
+		if err == nil {
+			err = ioutil.WriteFile(name, got, 0644)
+		}
+		if err != nil {
+			t.Fatalf("Writing golden file %s failed with error: %v", name, err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatalf("Reading golden file failed with error: %v (run the tests with -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Output differs from golden file %s (run the tests with -update to regenerate it)\ngot:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+// JSON returns v marshaled as indented JSON, followed by a new line, so that
+// golden files are readable and differences show up line by line.
+func JSON(t testing.TB, v interface{}) []byte {
+	t.Helper()
+
+	j, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatalf("Marshaling golden output failed with error: %v", err)
+	}
+	return append(j, '\n')
+}
+
+// IndentJSON returns the JSON document j indented like JSON does.
+func IndentJSON(t testing.TB, j []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	err := json.Indent(&buf, j, "", "  ")
+	if err != nil {
+		t.Fatalf("Indenting golden output failed with error: %v", err)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
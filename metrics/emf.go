@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultNamespace is the CloudWatch namespace of the metrics, if none is set.
+const DefaultNamespace = "ChangelogNightly"
+
+// EMF is a recorder writing the metrics as CloudWatch Embedded Metric Format
+// records, which CloudWatch Logs extracts metrics from. In Lambda the records
+// only have to be printed to stdout, no network access or AWS SDK is needed.
+// Durations are emitted in milliseconds, with a "_duration" suffix.
+type EMF struct {
+	W         io.Writer
+	Namespace string
+	// Now returns the timestamp of the records.
+	Now func() time.Time
+
+	mu        sync.Mutex
+	counters  map[string]float64
+	durations map[string][]float64
+}
+
+// NewEMF returns a recorder writing EMF records to w, in the namespace (or in
+// DefaultNamespace if empty).
+func NewEMF(w io.Writer, namespace string) *EMF {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	return &EMF{W: w, Namespace: namespace, Now: time.Now}
+}
+
+// Add adds value to the counter name.
+func (e *EMF) Add(name string, value float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.counters == nil {
+		e.counters = map[string]float64{}
+	}
+	e.counters[name] += value
+}
+
+// Observe records a duration of the operation name.
+func (e *EMF) Observe(name string, d time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.durations == nil {
+		e.durations = map[string][]float64{}
+	}
+	name += "_duration"
+	e.durations[name] = append(e.durations[name], float64(d)/float64(time.Millisecond))
+}
+
+type emfMetric struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+type emfDirective struct {
+	Namespace  string      `json:"Namespace"`
+	Dimensions [][]string  `json:"Dimensions"`
+	Metrics    []emfMetric `json:"Metrics"`
+}
+
+type emfMetadata struct {
+	Timestamp         int64          `json:"Timestamp"`
+	CloudWatchMetrics []emfDirective `json:"CloudWatchMetrics"`
+}
+
+// Flush writes a single record with the metrics recorded since the last flush,
+// and resets them. Nothing is written if no metrics were recorded.
+func (e *EMF) Flush() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.counters) == 0 && len(e.durations) == 0 {
+		return nil
+	}
+
+	record := map[string]interface{}{}
+	directive := emfDirective{Namespace: e.Namespace, Dimensions: [][]string{{}}}
+	for name, value := range e.counters {
+		record[name] = value
+		directive.Metrics = append(directive.Metrics, emfMetric{name, "Count"})
+	}
+	for name, values := range e.durations {
+		record[name] = values
+		directive.Metrics = append(directive.Metrics, emfMetric{name, "Milliseconds"})
+	}
+	sort.Slice(directive.Metrics, func(i, j int) bool {
+		return directive.Metrics[i].Name < directive.Metrics[j].Name
+	})
+	record["_aws"] = emfMetadata{
+		Timestamp:         e.Now().UnixNano() / int64(time.Millisecond),
+		CloudWatchMetrics: []emfDirective{directive},
+	}
+
+	j, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = e.W.Write(append(j, '\n'))
+	if err != nil {
+		return err
+	}
+	e.counters = nil
+	e.durations = nil
+	return nil
+}
@@ -0,0 +1,35 @@
+// Package metrics records counters and durations of runs and emits them as
+// CloudWatch Embedded Metric Format records or in the Prometheus text format.
+package metrics
+
+import (
+	"time"
+)
+
+// Recorder records metrics of runs. Implementations are safe for concurrent use.
+type Recorder interface {
+	// Add adds value to the counter name (eg. "repos_parsed").
+	Add(name string, value float64)
+	// Observe records how long an operation (eg. "download") took.
+	Observe(name string, d time.Duration)
+	// Flush emits the metrics recorded, if the recorder emits them in batches.
+	Flush() error
+}
+
+// Since records the time elapsed since start as a duration of the operation
+// name. It is meant to be deferred: defer metrics.Since(r, "download", time.Now()).
+func Since(r Recorder, name string, start time.Time) {
+	r.Observe(name, time.Since(start))
+}
+
+// Nop is a recorder that discards all metrics.
+type Nop struct{}
+
+// Add does nothing.
+func (Nop) Add(name string, value float64) {}
+
+// Observe does nothing.
+func (Nop) Observe(name string, d time.Duration) {}
+
+// Flush does nothing.
+func (Nop) Flush() error { return nil }
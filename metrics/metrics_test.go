@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// record() records the same metrics with r from several goroutines.
+func record(r Recorder) {
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Add("screenshots_found", 1)
+			r.Observe("download", 250*time.Millisecond)
+		}()
+	}
+	wg.Wait()
+	r.Add("repos_parsed", 30)
+}
+
+func TestEMF(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	e := NewEMF(&buf, "")
+	e.Now = func() time.Time { return time.Unix(1518048000, 0) }
+	record(e)
+	err := e.Flush()
+	if err != nil {
+		t.Fatalf("Flush() failed with error: %v", err)
+	}
+
+	var got struct {
+		AWS struct {
+			Timestamp         int64
+			CloudWatchMetrics []struct {
+				Namespace  string
+				Dimensions [][]string
+				Metrics    []struct{ Name, Unit string }
+			}
+		} `json:"_aws"`
+		ReposParsed      float64   `json:"repos_parsed"`
+		ScreenshotsFound float64   `json:"screenshots_found"`
+		Download         []float64 `json:"download_duration"`
+	}
+	err = json.Unmarshal(buf.Bytes(), &got)
+	if err != nil {
+		t.Fatalf("Record %s is not JSON: %v", buf.Bytes(), err)
+	}
+	if got.AWS.Timestamp != 1518048000000 || len(got.AWS.CloudWatchMetrics) != 1 {
+		t.Fatalf("Record %s has invalid metadata", buf.Bytes())
+	}
+	directive := got.AWS.CloudWatchMetrics[0]
+	if directive.Namespace != DefaultNamespace || len(directive.Metrics) != 3 ||
+		directive.Metrics[0].Name != "download_duration" || directive.Metrics[0].Unit != "Milliseconds" ||
+		directive.Metrics[1].Name != "repos_parsed" || directive.Metrics[1].Unit != "Count" {
+		t.Errorf("Directive = %+v", directive)
+	}
+	if got.ReposParsed != 30 || got.ScreenshotsFound != 4 || len(got.Download) != 4 || got.Download[0] != 250 {
+		t.Errorf("Record %s has invalid values", buf.Bytes())
+	}
+
+	// Metrics are reset after a flush
+	buf.Reset()
+	err = e.Flush()
+	if err != nil || buf.Len() != 0 {
+		t.Errorf("Flush() without new metrics wrote %q, error: %v", buf.String(), err)
+	}
+}
+
+func TestPrometheus(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "nightly.prom")
+	p := NewPrometheus(file)
+	record(p)
+	err := p.Flush()
+	if err != nil {
+		t.Fatalf("Flush() failed with error: %v", err)
+	}
+
+	want := `# TYPE nightly_repos_parsed_total counter
+nightly_repos_parsed_total 30
+# TYPE nightly_screenshots_found_total counter
+nightly_screenshots_found_total 4
+# TYPE nightly_download_duration_seconds summary
+nightly_download_duration_seconds_sum 1
+nightly_download_duration_seconds_count 4
+`
+	got, err := ioutil.ReadFile(file)
+	if err != nil || string(got) != want {
+		t.Errorf("Metrics file = %q, %v, want %q", got, err, want)
+	}
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Body.String() != want {
+		t.Errorf("ServeHTTP() = %q, want %q", rec.Body.String(), want)
+	}
+
+	// Totals are kept after a flush
+	p.Add("repos_parsed", 10)
+	var buf bytes.Buffer
+	p.WriteTo(&buf)
+	if !bytes.Contains(buf.Bytes(), []byte("nightly_repos_parsed_total 40\n")) {
+		t.Errorf("WriteTo() = %q, want the total of both runs", buf.String())
+	}
+}
+
+func TestNop(t *testing.T) {
+	t.Parallel()
+
+	var r Recorder = Nop{}
+	record(r)
+	if err := r.Flush(); err != nil {
+		t.Errorf("Flush() failed with error: %v", err)
+	}
+}
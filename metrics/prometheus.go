@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Prefix is prepended to the names of all metrics in the Prometheus format.
+const Prefix = "nightly_"
+
+// Prometheus is a recorder keeping the totals of all metrics since it was created,
+// exposed in the Prometheus text format. Counters are exposed as "<name>_total",
+// durations as summaries named "<name>_duration_seconds".
+//
+// A long running process can serve the metrics over HTTP, as Prometheus implements
+// http.Handler. Short lived commands can set File instead, to have Flush write
+// the metrics to a file collected by the textfile collector of node_exporter.
+type Prometheus struct {
+	// File the metrics are written to by Flush, if not empty.
+	File string
+
+	mu        sync.Mutex
+	counters  map[string]float64
+	durations map[string]*summary
+}
+
+// summary holds the sum and count of the observations of a duration.
+type summary struct {
+	sum   float64
+	count int
+}
+
+// NewPrometheus returns a recorder writing the metrics to file on Flush, or only
+// keeping them in memory if file is empty.
+func NewPrometheus(file string) *Prometheus {
+	return &Prometheus{File: file}
+}
+
+// Add adds value to the counter name.
+func (p *Prometheus) Add(name string, value float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.counters == nil {
+		p.counters = map[string]float64{}
+	}
+	p.counters[name] += value
+}
+
+// Observe records a duration of the operation name.
+func (p *Prometheus) Observe(name string, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.durations == nil {
+		p.durations = map[string]*summary{}
+	}
+	s := p.durations[name]
+	if s == nil {
+		s = &summary{}
+		p.durations[name] = s
+	}
+	s.sum += d.Seconds()
+	s.count++
+}
+
+// WriteTo writes all metrics in the Prometheus text format to w, sorted by name.
+func (p *Prometheus) WriteTo(w io.Writer) (int64, error) {
+	p.mu.Lock()
+	counters := []string{}
+	for name := range p.counters {
+		counters = append(counters, name)
+	}
+	sort.Strings(counters)
+	durations := []string{}
+	for name := range p.durations {
+		durations = append(durations, name)
+	}
+	sort.Strings(durations)
+
+	var buf bytes.Buffer
+	for _, name := range counters {
+		fmt.Fprintf(&buf, "# TYPE %s%s_total counter\n", Prefix, name)
+		fmt.Fprintf(&buf, "%s%s_total %g\n", Prefix, name, p.counters[name])
+	}
+	for _, name := range durations {
+		s := p.durations[name]
+		fmt.Fprintf(&buf, "# TYPE %s%s_duration_seconds summary\n", Prefix, name)
+		fmt.Fprintf(&buf, "%s%s_duration_seconds_sum %g\n", Prefix, name, s.sum)
+		fmt.Fprintf(&buf, "%s%s_duration_seconds_count %d\n", Prefix, name, s.count)
+	}
+	p.mu.Unlock()
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// ServeHTTP responds with all metrics in the Prometheus text format.
+func (p *Prometheus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	p.WriteTo(w)
+}
+
+// Flush writes all metrics to File, if set. The file is replaced atomically, so
+// that collectors never read a partially written file.
+func (p *Prometheus) Flush() error {
+	if p.File == "" {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	p.WriteTo(&buf)
+	tmp, err := ioutil.TempFile(filepath.Dir(p.File), filepath.Base(p.File)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(buf.Bytes())
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p.File)
+}
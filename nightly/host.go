@@ -0,0 +1,130 @@
+package nightly
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Host is a site hosting repositories, which knows where to find the readme
+// and the raw files of a repository.
+type Host interface {
+	// PageURL returns the URL of the web page of the repository.
+	PageURL(owner string, name string) string
+	// ReadmeURL returns the URL for downloading the Markdown source of the
+	// default readme of the repository.
+	ReadmeURL(owner string, name string) string
+	// RawURL returns the URL of the raw content of the file at path on the given branch.
+	RawURL(owner string, name string, branch string, path string) string
+}
+
+// GitHub is the github.com host. Readmes are requested from the Github API.
+type GitHub struct{}
+
+// PageURL returns the URL of the repository on github.com.
+func (GitHub) PageURL(owner string, name string) string {
+	return fmt.Sprintf("https://github.com/%s/%s", owner, name)
+}
+
+// ReadmeURL returns the Github API URL of the readme
+// (eg. https://api.github.com/repos/user1/repo1/readme).
+func (GitHub) ReadmeURL(owner string, name string) string {
+	return fmt.Sprintf("https://api.github.com/repos/%s/%s/readme", owner, name)
+}
+
+// RawURL returns the URL of the file on raw.githubusercontent.com
+// (eg. https://raw.githubusercontent.com/user1/repo1/master/screenshot.jpg).
+func (GitHub) RawURL(owner string, name string, branch string, path string) string {
+	return fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s", owner, name, branch, path)
+}
+
+// GitLab is the gitlab.com host. The owner of a GitLab repository is the full
+// path of its group, which may contain subgroups (eg. "group/subgroup").
+type GitLab struct{}
+
+// PageURL returns the URL of the project on gitlab.com.
+func (GitLab) PageURL(owner string, name string) string {
+	return fmt.Sprintf("https://gitlab.com/%s/%s", owner, name)
+}
+
+// ReadmeURL returns the URL of the raw README.md on the default branch
+// (eg. https://gitlab.com/group/project/-/raw/HEAD/README.md).
+func (h GitLab) ReadmeURL(owner string, name string) string {
+	return h.RawURL(owner, name, "HEAD", "README.md")
+}
+
+// RawURL returns the URL of the raw file (eg. https://gitlab.com/group/project/-/raw/main/screenshot.jpg).
+func (GitLab) RawURL(owner string, name string, branch string, path string) string {
+	return fmt.Sprintf("https://gitlab.com/%s/%s/-/raw/%s/%s", owner, name, branch, path)
+}
+
+// RepoRef identifies a repository on a host.
+type RepoRef struct {
+	Host  Host
+	Owner string
+	Name  string
+}
+
+// ParseRepoURL parses the URL of a repository page into the host, owner and
+// name of the repository. Paths below the repository page (eg. /tree/dev) are
+// ignored. Returns an error for hosts other than github.com and gitlab.com.
+func ParseRepoURL(repoURL string) (*RepoRef, error) {
+	u, err := url.Parse(strings.TrimSpace(repoURL))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid repository URL %q: %v", repoURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("Invalid repository URL %q, expected an http or https URL", repoURL)
+	}
+
+	segments := []string{}
+	for _, s := range strings.Split(u.Path, "/") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+
+	ref := &RepoRef{}
+	switch strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.") {
+	case "github.com":
+		ref.Host = GitHub{}
+		if len(segments) >= 2 {
+			ref.Owner, ref.Name = segments[0], segments[1]
+		}
+	case "gitlab.com":
+		ref.Host = GitLab{}
+		// Pages of a project are separated from the project path by "/-/"
+		for i, s := range segments {
+			if s == "-" {
+				segments = segments[:i]
+				break
+			}
+		}
+		if len(segments) >= 2 {
+			ref.Owner, ref.Name = strings.Join(segments[:len(segments)-1], "/"), segments[len(segments)-1]
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported repository host %q in %q", u.Host, repoURL)
+	}
+
+	ref.Name = strings.TrimSuffix(ref.Name, ".git")
+	if ref.Owner == "" || ref.Name == "" {
+		return nil, fmt.Errorf("Invalid repository URL %q, expected owner and name in the path", repoURL)
+	}
+	return ref, nil
+}
+
+// FullName returns the owner and the name of the repository, separated by a slash.
+func (ref *RepoRef) FullName() string {
+	return ref.Owner + "/" + ref.Name
+}
+
+// ReadmeURL returns the URL for downloading the default readme of the repository.
+func (ref *RepoRef) ReadmeURL() string {
+	return ref.Host.ReadmeURL(ref.Owner, ref.Name)
+}
+
+// RawURL returns the URL of the raw content of the file at path on the given branch.
+func (ref *RepoRef) RawURL(branch string, path string) string {
+	return ref.Host.RawURL(ref.Owner, ref.Name, branch, path)
+}
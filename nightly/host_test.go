@@ -0,0 +1,42 @@
+package nightly
+
+import (
+	"testing"
+)
+
+func TestParseRepoURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		wantHost  Host
+		wantOwner string
+		wantName  string
+		wantErr   bool
+	}{
+		{"GitHub", "https://github.com/user/repo", GitHub{}, "user", "repo", false},
+		{"GitHub with www and upper case host", "https://WWW.GitHub.com/user/repo", GitHub{}, "user", "repo", false},
+		{"GitHub with path suffix", "https://github.com/user/repo/tree/dev/docs", GitHub{}, "user", "repo", false},
+		{"GitHub with .git suffix", "https://github.com/user/repo.git", GitHub{}, "user", "repo", false},
+		{"GitHub with query", "https://github.com/user/repo?tab=readme", GitHub{}, "user", "repo", false},
+		{"GitLab", "https://gitlab.com/group/repo", GitLab{}, "group", "repo", false},
+		{"GitLab subgroup", "https://gitlab.com/group/sub/repo/-/tree/main", GitLab{}, "group/sub", "repo", false},
+		{"Missing name", "https://github.com/user", nil, "", "", true},
+		{"Bitbucket", "https://bitbucket.org/user/repo", nil, "", "", true},
+		{"Codeberg", "https://codeberg.org/user/repo", nil, "", "", true},
+		{"Not a URL", "user/repo", nil, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRepoURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRepoURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Host != tt.wantHost || got.Owner != tt.wantOwner || got.Name != tt.wantName {
+				t.Errorf("ParseRepoURL() = %+v, want %T %s/%s", got, tt.wantHost, tt.wantOwner, tt.wantName)
+			}
+		})
+	}
+}
@@ -1,9 +1,10 @@
-package main
+// Package nightly parses the Changelog Nightly page (http://nightly.changelog.com/YYYY/MM/DD/),
+// which lists the repositories trending on Github on the given day.
+package nightly
 
 import (
 	"fmt"
 	"io"
-	"log"
 	"strconv"
 	"strings"
 
@@ -38,6 +39,14 @@ func parseRepository(parent *html.Node) (*Repository, error) {
 		}
 	}
 
+	ns := htmlquery.FindOne(parent, `//span[@title='New Stars']`)
+	if ns != nil {
+		sn, err := strconv.Atoi(strings.TrimSpace(htmlquery.InnerText(ns)))
+		if err == nil {
+			repo.NewStars = sn
+		}
+	}
+
 	l := htmlquery.FindOne(parent, `//span[contains(@title, 'Language')]//a`)
 	if l != nil {
 		repo.Language = strings.TrimSpace(htmlquery.InnerText(l))
@@ -61,12 +70,12 @@ func parseCategory(parent *html.Node, category string) []Repository {
 	return list
 }
 
-// parseNightlyPage extracts all repository links from the ChangeLog's nightly page
+// Parse extracts all repositories from the ChangeLog's nightly page
 // (http://nightly.changelog.com/YYYY/MM/DD/) in all three categories:
 // - Top Starred Repositories – First Timers
 // - Top New Repositories
 // - Top Starred Repositories – Repeat Performers
-func parseNightlyPage(body io.Reader) (*TrendingRepos, error) {
+func Parse(body io.Reader) (*TrendingRepos, error) {
 	trending := TrendingRepos{}
 
 	doc, err := htmlquery.Parse(body)
@@ -78,9 +87,5 @@ func parseNightlyPage(body io.Reader) (*TrendingRepos, error) {
 	trending.New = parseCategory(doc, "top-new")
 	trending.Repeaters = parseCategory(doc, "top-all-repeats")
 
-	totalCount := len(trending.First) + len(trending.New) + len(trending.Repeaters)
-
-	log.Printf("Found %d repositories", totalCount)
-
 	return &trending, nil
 }
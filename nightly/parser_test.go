@@ -0,0 +1,126 @@
+package nightly
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/quasoft/changelog-nightly-parser/internal/golden"
+)
+
+func TestParse(t *testing.T) {
+	r, err := os.Open("testdata/nightly.html")
+	if err != nil {
+		t.Fatalf("failed opening sample page. error: %v", err)
+	}
+	defer r.Close()
+
+	trending, err := Parse(r)
+	if err != nil {
+		t.Fatalf("failed parsing on HTML in expected format. error: %v", err)
+	}
+
+	gotLen := len(trending.First)
+	wantLen := 2
+	if gotLen != wantLen {
+		t.Fatalf("Found %v first timers, want %v", gotLen, wantLen)
+	}
+
+	got := trending.First[0]
+	wantURL := "https://github.com/user1/repo1"
+	if got.URL != wantURL {
+		t.Errorf("trending.First[0].URL = %v, want %v", got.URL, wantURL)
+	}
+	wantName := "user1/repo1"
+	if got.Name != wantName {
+		t.Errorf("trending.First[0].Name = %v, want %q", got.Name, wantName)
+	}
+	wantDesc := "A non existing C library."
+	if got.Description != wantDesc {
+		t.Errorf("trending.First[0].Description = %v, want %q", got.Description, wantDesc)
+	}
+	wantStars := 168
+	if got.Stars != wantStars {
+		t.Errorf("trending.First[0].Stars = %v, want %v", got.Stars, wantStars)
+	}
+	wantNewStars := 90
+	if got.NewStars != wantNewStars {
+		t.Errorf("trending.First[0].NewStars = %v, want %v", got.NewStars, wantNewStars)
+	}
+	wantLang := "C"
+	if got.Language != wantLang {
+		t.Errorf("trending.First[0].Language = %v, want %q", got.Language, wantLang)
+	}
+
+	got = trending.First[1]
+	wantURL = "https://github.com/user2/repo2"
+	if got.URL != wantURL {
+		t.Errorf("trending.First[1].URL = %v, want %v", got.URL, wantURL)
+	}
+
+	gotLen = len(trending.New)
+	wantLen = 1
+	if gotLen != wantLen {
+		t.Errorf("Found %v new repos, want %v", gotLen, gotLen)
+	}
+	got = trending.New[0]
+	wantURL = "https://github.com/user3/repo3"
+	if got.URL != wantURL {
+		t.Errorf("trending.New[0].URL = %v, want %v", got.URL, wantURL)
+	}
+
+	gotLen = len(trending.Repeaters)
+	gotLen = 1
+	if gotLen != gotLen {
+		t.Errorf("Found %v repeat performers, want %v", gotLen, gotLen)
+	}
+	got = trending.Repeaters[0]
+	wantURL = "https://github.com/user4/repo4"
+	if got.URL != wantURL {
+		t.Errorf("trending.Repeaters[0].URL = %v, want %v", got.URL, wantURL)
+	}
+}
+
+func TestTrendingRepos_Count(t *testing.T) {
+	tr := TrendingRepos{
+		First:     []Repository{{}, {}},
+		New:       []Repository{{}},
+		Repeaters: []Repository{{}},
+	}
+	if got := tr.Count(); got != 4 {
+		t.Errorf("TrendingRepos.Count() = %v, want %v", got, 4)
+	}
+}
+
+// goldenArchive is the directory of the nightly pages recorded for the golden
+// tests, shared with the golden tests of the pipeline.
+const goldenArchive = "../pipeline/testdata/archive/nightly.changelog.com"
+
+// TestParse_Golden parses every page recorded in goldenArchive and compares the
+// result with testdata/golden/YYYY-MM-DD.json. Run with -update to regenerate
+// the JSON files.
+func TestParse_Golden(t *testing.T) {
+	pages, err := filepath.Glob(filepath.Join(goldenArchive, "*", "*", "*.html"))
+	if err != nil || len(pages) == 0 {
+		t.Fatalf("No golden pages found, error: %v", err)
+	}
+
+	for _, page := range pages {
+		rel, _ := filepath.Rel(goldenArchive, page)
+		date := strings.Replace(strings.TrimSuffix(filepath.ToSlash(rel), ".html"), "/", "-", -1)
+		t.Run(date, func(t *testing.T) {
+			r, err := os.Open(page)
+			if err != nil {
+				t.Fatalf("failed opening page. error: %v", err)
+			}
+			defer r.Close()
+
+			trending, err := Parse(r)
+			if err != nil {
+				t.Fatalf("Parse() failed with error: %v", err)
+			}
+			golden.Assert(t, filepath.Join("testdata", "golden", date+".json"), golden.JSON(t, trending))
+		})
+	}
+}
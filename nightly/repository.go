@@ -0,0 +1,140 @@
+package nightly
+
+import (
+	"strings"
+	"time"
+)
+
+// Repository contains fields for the most relevant information available for each repository.
+type Repository struct {
+	Name        string `json:"Name"`
+	URL         string `json:"URL"`
+	Description string `json:"Description"`
+	Stars       int    `json:"Stars"`
+	// NewStars is the number of stars gained on the day.
+	NewStars   int    `json:"NewStars"`
+	Language   string `json:"Language"`
+	Screenshot string `json:"Screenshot"`
+	// Categories lists all categories the repository appeared in, if duplicates
+	// were merged with Dedup.
+	Categories []string `json:"Categories,omitempty"`
+	// Metadata is only available if the repositories were enriched with
+	// information from the Github API.
+	Metadata *Metadata `json:"Metadata,omitempty"`
+}
+
+// Metadata contains information about a repository that is not available on
+// the nightly page.
+type Metadata struct {
+	Topics     []string  `json:"Topics"`
+	License    string    `json:"License"`
+	Forks      int       `json:"Forks"`
+	OpenIssues int       `json:"OpenIssues"`
+	CreatedAt  time.Time `json:"CreatedAt"`
+	PushedAt   time.Time `json:"PushedAt"`
+	Homepage   string    `json:"Homepage"`
+	Archived   bool      `json:"Archived"`
+}
+
+// Names of the categories, as used in the JSON files.
+const (
+	FirstTimers      = "FirstTimers"
+	TopNew           = "TopNew"
+	RepeatPerformers = "RepeatPerformers"
+)
+
+// TrendingRepos is the structure used for marshaling the trending repositories to JSON.
+// The three fields represent the three categories on Changelog's Nightly page:
+// - First - repositories featured for the first time in the Changelog
+// - New - new open sourced repositories
+// - Repeaters - trending repos that have been featured before
+type TrendingRepos struct {
+	First     []Repository `json:"FirstTimers"`
+	New       []Repository `json:"TopNew"`
+	Repeaters []Repository `json:"RepeatPerformers"`
+}
+
+// Ref returns the host, owner and name of the repository, parsed from its URL.
+func (r *Repository) Ref() (*RepoRef, error) {
+	return ParseRepoURL(r.URL)
+}
+
+// ReadmeURL returns the URL for downloading the default readme of the repository
+// (eg. https://api.github.com/repos/user1/repo1/readme for Github repositories).
+// Returns an error if the repository is not on a supported host.
+func (r *Repository) ReadmeURL() (string, error) {
+	ref, err := r.Ref()
+	if err != nil {
+		return "", err
+	}
+	return ref.ReadmeURL(), nil
+}
+
+// RawImageURL returns the absolute URL to an image hosted inside a repository,
+// given the branch name and the relative path to the image
+// (eg. https://raw.githubusercontent.com/user1/repo1/master/screenshot.jpg).
+// Returns an error if the repository is not on a supported host.
+func (r *Repository) RawImageURL(branch string, relativePath string) (string, error) {
+	ref, err := r.Ref()
+	if err != nil {
+		return "", err
+	}
+	return ref.RawURL(branch, relativePath), nil
+}
+
+// CanonicalURL returns the URL of the repository page in a canonical form, so
+// that variants of the URL can be compared: https, without "www.", without
+// paths below the repository (eg. /tree/dev) and with the owner and name in
+// lower case, as they are case-insensitive on the supported hosts.
+// URLs of other hosts are only lower-cased and stripped of "www." and trailing slashes.
+func (r *Repository) CanonicalURL() string {
+	ref, err := r.Ref()
+	if err == nil {
+		return strings.ToLower(ref.Host.PageURL(ref.Owner, ref.Name))
+	}
+
+	u := strings.ToLower(strings.TrimSpace(r.URL))
+	u = strings.TrimPrefix(strings.TrimPrefix(u, "http://"), "https://")
+	u = strings.TrimPrefix(u, "www.")
+	return "https://" + strings.TrimRight(u, "/")
+}
+
+// Dedup merges repositories listed more than once (with any variant of their
+// URL, see CanonicalURL) into their first entry, in the order of the nightly page,
+// and records the categories of all entries in Categories of the entry kept.
+func (tr *TrendingRepos) Dedup() {
+	all := []*[]Repository{&tr.First, &tr.New, &tr.Repeaters}
+	names := []string{FirstTimers, TopNew, RepeatPerformers}
+
+	// Find the categories of each repository first, as entries are moved around
+	// when the duplicates are removed
+	categories := map[string][]string{}
+	for cat, repos := range all {
+		for _, r := range *repos {
+			u := r.CanonicalURL()
+			if c := categories[u]; len(c) == 0 || c[len(c)-1] != names[cat] {
+				categories[u] = append(c, names[cat])
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, repos := range all {
+		unique := []Repository{}
+		for _, r := range *repos {
+			u := r.CanonicalURL()
+			if seen[u] {
+				continue
+			}
+			seen[u] = true
+			r.Categories = categories[u]
+			unique = append(unique, r)
+		}
+		*repos = unique
+	}
+}
+
+// Count returns the number of repositories in all three categories.
+func (tr *TrendingRepos) Count() int {
+	return len(tr.First) + len(tr.New) + len(tr.Repeaters)
+}
@@ -0,0 +1,139 @@
+package nightly
+
+import (
+	"testing"
+)
+
+func TestRepository_ReadmeURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		r       Repository
+		want    string
+		wantErr bool
+	}{
+		{"Short", Repository{URL: "https://github.com/user/repo"}, "https://api.github.com/repos/user/repo/readme", false},
+		{"With www", Repository{URL: "https://www.github.com/user/repo"}, "https://api.github.com/repos/user/repo/readme", false},
+		{"Http", Repository{URL: "http://github.com/user/repo"}, "https://api.github.com/repos/user/repo/readme", false},
+		{"With trailing slash", Repository{URL: "http://www.github.com/user/repo/"}, "https://api.github.com/repos/user/repo/readme", false},
+		{"With path suffix", Repository{URL: "https://github.com/user/repo/tree/dev"}, "https://api.github.com/repos/user/repo/readme", false},
+		{"GitLab", Repository{URL: "https://gitlab.com/group/repo"}, "https://gitlab.com/group/repo/-/raw/HEAD/README.md", false},
+		{"Unsupported host", Repository{URL: "https://bitbucket.org/user/repo"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.r.ReadmeURL()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Repository.ReadmeURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Repository.ReadmeURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepository_RawImageURL(t *testing.T) {
+	type args struct {
+		branch       string
+		relativePath string
+	}
+	tests := []struct {
+		name    string
+		r       Repository
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			"Relative image from repository",
+			Repository{URL: "https://github.com/user/repo"}, args{branch: "master", relativePath: "images/screenshot.jpg"},
+			"https://raw.githubusercontent.com/user/repo/master/images/screenshot.jpg", false,
+		},
+		{
+			"Relative image from repository with www",
+			Repository{URL: "https://www.github.com/user/repo"}, args{branch: "master", relativePath: "images/image.jpg"},
+			"https://raw.githubusercontent.com/user/repo/master/images/image.jpg", false,
+		},
+		{
+			"Relative image from repository with http",
+			Repository{URL: "http://github.com/user/repo"}, args{branch: "master", relativePath: "images/demo.png"},
+			"https://raw.githubusercontent.com/user/repo/master/images/demo.png", false,
+		},
+		{
+			"Relative image from repository with path suffix",
+			Repository{URL: "https://github.com/user/repo/tree/dev"}, args{branch: "dev", relativePath: "demo.png"},
+			"https://raw.githubusercontent.com/user/repo/dev/demo.png", false,
+		},
+		{
+			"Relative image from GitLab repository",
+			Repository{URL: "https://gitlab.com/group/repo"}, args{branch: "main", relativePath: "demo.png"},
+			"https://gitlab.com/group/repo/-/raw/main/demo.png", false,
+		},
+		{
+			"Unsupported host",
+			Repository{URL: "https://codeberg.org/user/repo"}, args{branch: "main", relativePath: "demo.png"},
+			"", true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.r.RawImageURL(tt.args.branch, tt.args.relativePath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Repository.RawImageURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Repository.RawImageURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepository_CanonicalURL(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"Canonical", "https://github.com/user/repo", "https://github.com/user/repo"},
+		{"With www and http", "http://www.github.com/user/repo", "https://github.com/user/repo"},
+		{"With trailing slash and upper case", "https://github.com/User/Repo/", "https://github.com/user/repo"},
+		{"With path suffix", "https://github.com/user/repo/tree/dev", "https://github.com/user/repo"},
+		{"GitLab", "https://gitlab.com/Group/Sub/Repo/-/tree/main", "https://gitlab.com/group/sub/repo"},
+		{"Unsupported host", "http://www.Codeberg.org/user/repo/", "https://codeberg.org/user/repo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Repository{URL: tt.url}
+			if got := r.CanonicalURL(); got != tt.want {
+				t.Errorf("Repository.CanonicalURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrendingRepos_Dedup(t *testing.T) {
+	tr := TrendingRepos{
+		First: []Repository{
+			{Name: "user/repo", URL: "https://github.com/user/repo", Stars: 1},
+			{Name: "user/other", URL: "https://github.com/user/other"},
+			{Name: "User/Repo", URL: "https://www.github.com/User/Repo/"},
+		},
+		New: []Repository{},
+		Repeaters: []Repository{
+			{Name: "user/third", URL: "https://github.com/user/third"},
+			{Name: "user/repo", URL: "http://github.com/user/repo", Stars: 2},
+		},
+	}
+	tr.Dedup()
+
+	if len(tr.First) != 2 || len(tr.New) != 0 || len(tr.Repeaters) != 1 {
+		t.Fatalf("TrendingRepos.Dedup() = %+v", tr)
+	}
+	repo := tr.First[0]
+	if repo.Stars != 1 || len(repo.Categories) != 2 || repo.Categories[0] != FirstTimers || repo.Categories[1] != RepeatPerformers {
+		t.Errorf("TrendingRepos.Dedup() kept %+v, want the first entry in FirstTimers and RepeatPerformers", repo)
+	}
+	if len(tr.First[1].Categories) != 1 || tr.Repeaters[0].Name != "user/third" || tr.Repeaters[0].Categories[0] != RepeatPerformers {
+		t.Errorf("TrendingRepos.Dedup() = %+v", tr)
+	}
+}
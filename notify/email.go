@@ -0,0 +1,240 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+// smtpTimeout limits the whole SMTP session, from connecting to QUIT.
+const smtpTimeout = 30 * time.Second
+
+var emailTextTemplate = template.Must(template.New("text").Parse(`Trending repositories for {{.Date}}: {{.Total}}
+{{range .Categories}}
+{{.Title}} ({{len .Repos}})
+{{range .Repos}}
+- {{.Name}} ({{.Stars}} stars{{if .NewStars}}, +{{.NewStars}}{{end}}{{if .Language}}, {{.Language}}{{end}})
+  {{.URL}}{{if .Description}}
+  {{.Description}}{{end}}{{if .Screenshot}}
+  Screenshot: {{.Screenshot}}{{end}}
+{{end}}{{end}}{{if .FileURL}}
+{{.FileURL}}
+{{end}}`))
+
+var emailHTMLTemplate = htmltemplate.Must(htmltemplate.New("html").Parse(`<!DOCTYPE html>
+<html>
+<body>
+{{if .Failed}}<p>Publishing trending repositories for {{.Date}} failed: {{.Error}}</p>
+{{else}}<h1>Trending repositories for {{.Date}}</h1>
+{{range .Categories}}<h2>{{.Title}}</h2>
+{{range .Repos}}<div>
+<h3><a href="{{.URL}}">{{.Name}}</a></h3>
+<p>{{.Stars}} stars{{if .NewStars}}, +{{.NewStars}}{{end}}{{if .Language}}, {{.Language}}{{end}}</p>
+{{if .Description}}<p>{{.Description}}</p>
+{{end}}{{if .Screenshot}}<a href="{{.URL}}"><img src="{{.Screenshot}}" alt="{{.Name}}" style="max-width: 600px"></a>
+{{end}}</div>
+{{end}}{{end}}{{if .FileURL}}<p><a href="{{.FileURL}}">{{.FileURL}}</a></p>
+{{end}}{{end}}</body>
+</html>
+`))
+
+// emailCategory is a category of trending repos, as listed in the email digest.
+type emailCategory struct {
+	Title string
+	Repos []nightly.Repository
+}
+
+// Email sends the trending repos of the day as a multipart HTML/text email via
+// SMTP. Screenshots are linked from the HTML part, not attached.
+type Email struct {
+	// Addr is the host:port of the SMTP server.
+	Addr string
+	// Username and Password are used for PLAIN authentication, if Username is set.
+	Username string
+	Password string
+	From     string
+	To       []string
+	// StartTLS requires the server to support STARTTLS. STARTTLS is used whenever
+	// the server supports it, even if not required.
+	StartTLS bool
+	// TLSConfig is used for STARTTLS, if not nil. By default the certificate of
+	// the server is verified for the host in Addr.
+	TLSConfig *tls.Config
+	Config    *Config
+}
+
+// Notify emails the trending repos of the day, or the error if the run failed.
+func (e *Email) Notify(ctx context.Context, s *Summary) error {
+	msg, err := e.message(s, time.Now())
+	if err != nil {
+		return err
+	}
+	err = e.send(ctx, msg)
+	if err != nil {
+		return fmt.Errorf("Sending email via %s failed: %v", e.Addr, err)
+	}
+	return nil
+}
+
+// message() renders the email with the summary, dated now.
+func (e *Email) message(s *Summary, now time.Time) ([]byte, error) {
+	data := struct {
+		*Summary
+		Categories []emailCategory
+	}{Summary: s}
+	if s.Trending != nil {
+		for _, c := range []emailCategory{
+			{"First time trending", s.Trending.First},
+			{"Top new repositories", s.Trending.New},
+			{"Repeat performers", s.Trending.Repeaters},
+		} {
+			if len(c.Repos) > 0 {
+				data.Categories = append(data.Categories, c)
+			}
+		}
+	}
+
+	subject := fmt.Sprintf("Trending repositories for %s", s.Date)
+	var text bytes.Buffer
+	if s.Failed() {
+		subject = fmt.Sprintf("Publishing trending repositories for %s failed", s.Date)
+		msg, err := e.Config.message(s)
+		if err != nil {
+			return nil, err
+		}
+		text.WriteString(msg)
+	} else {
+		err := emailTextTemplate.Execute(&text, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var html bytes.Buffer
+	err := emailHTMLTemplate.Execute(&html, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		contentType string
+		content     []byte
+	}{
+		{"text/plain; charset=utf-8", text.Bytes()},
+		{"text/html; charset=utf-8", html.Bytes()},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		_, err = qp.Write(part.content)
+		if err != nil {
+			return nil, err
+		}
+		err = qp.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = mw.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", mw.Boundary())
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+// send() delivers the message to all recipients in a single SMTP session, which
+// ends after smtpTimeout or when the context is done, whichever comes first.
+func (e *Email) send(ctx context.Context, msg []byte) error {
+	host, _, err := net.SplitHostPort(e.Addr)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", e.Addr)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		cfg := e.TLSConfig
+		if cfg == nil {
+			cfg = &tls.Config{ServerName: host}
+		}
+		err = c.StartTLS(cfg)
+		if err != nil {
+			return err
+		}
+	} else if e.StartTLS {
+		return fmt.Errorf("Server does not support STARTTLS")
+	}
+
+	if e.Username != "" {
+		err = c.Auth(smtp.PlainAuth("", e.Username, e.Password, host))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = c.Mail(e.From)
+	if err != nil {
+		return err
+	}
+	for _, to := range e.To {
+		err = c.Rcpt(to)
+		if err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(msg)
+	if err != nil {
+		return err
+	}
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+	return c.Quit()
+}
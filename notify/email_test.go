@@ -0,0 +1,324 @@
+package notify
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// smtpMail is a message received by smtpServer.
+type smtpMail struct {
+	From string
+	To   []string
+	Auth string
+	TLS  bool
+	Data string
+}
+
+// smtpServer is a fake SMTP server, accepting any message and credentials. It
+// offers STARTTLS if TLSConfig is set.
+type smtpServer struct {
+	Addr      string
+	TLSConfig *tls.Config
+
+	mu    sync.Mutex
+	mails []smtpMail
+}
+
+func newSMTPServer(t *testing.T, tlsConfig *tls.Config) *smtpServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() failed with error: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	s := &smtpServer{Addr: l.Addr().String(), TLSConfig: tlsConfig}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer func() { conn.Close() }()
+
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost ESMTP")
+	m := smtpMail{}
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-localhost")
+			if s.TLSConfig != nil && !m.TLS {
+				tp.PrintfLine("250-STARTTLS")
+			}
+			tp.PrintfLine("250 AUTH PLAIN")
+		case "STARTTLS":
+			tp.PrintfLine("220 Ready to start TLS")
+			tlsConn := tls.Server(conn, s.TLSConfig)
+			if tlsConn.Handshake() != nil {
+				return
+			}
+			conn = tlsConn
+			tp = textproto.NewConn(conn)
+			m.TLS = true
+		case "AUTH":
+			m.Auth = line
+			tp.PrintfLine("235 Authenticated")
+		case "MAIL":
+			m.From = strings.Trim(line[len("MAIL FROM:"):], "<>")
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			m.To = append(m.To, strings.Trim(line[len("RCPT TO:"):], "<>"))
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 Send data")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			m.Data = string(data)
+			s.mu.Lock()
+			s.mails = append(s.mails, m)
+			s.mu.Unlock()
+			tp.PrintfLine("250 Queued")
+		case "QUIT":
+			tp.PrintfLine("221 Bye")
+			return
+		default:
+			tp.PrintfLine("250 OK")
+		}
+	}
+}
+
+func (s *smtpServer) received() []smtpMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mails
+}
+
+// newTestTLS() returns the configuration of a server with a self-signed certificate
+// for 127.0.0.1, and the configuration of a client trusting it.
+func newTestTLS(t *testing.T) (*tls.Config, *tls.Config) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() failed with error: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() failed with error: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+
+	server := &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	client := &tls.Config{ServerName: "127.0.0.1", RootCAs: roots}
+	return server, client
+}
+
+// parseEmail() returns the subject, and the text and HTML parts of the message.
+func parseEmail(t *testing.T, data string) (string, string, string) {
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadMessage() failed with error: %v", err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %s, want multipart/alternative", msg.Header.Get("Content-Type"))
+	}
+
+	parts := map[string]string{}
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := r.NextPart()
+		if err != nil {
+			break
+		}
+		b, _ := ioutil.ReadAll(p)
+		contentType, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		parts[contentType] = string(b)
+	}
+	return subject, parts["text/plain"], parts["text/html"]
+}
+
+func TestEmail_Notify(t *testing.T) {
+	t.Parallel()
+
+	serverTLS, clientTLS := newTestTLS(t)
+	server := newSMTPServer(t, serverTLS)
+	cfg, _ := LoadConfig(testEnv(nil))
+	e := &Email{
+		Addr:      server.Addr,
+		Username:  "user",
+		Password:  "secret",
+		From:      "nightly@example.com",
+		To:        []string{"jane@example.com", "joe@example.com"},
+		StartTLS:  true,
+		TLSConfig: clientTLS,
+		Config:    cfg,
+	}
+	s := testSummary()
+	s.Trending.First[0].Screenshot = "https://raw.githubusercontent.com/acme/rocket/master/screenshot.png"
+	s.Trending.First[0].Description = "Fast & <safe> rockets"
+
+	err := e.Notify(context.Background(), s)
+	if err != nil {
+		t.Fatalf("Notify() failed with error: %v", err)
+	}
+
+	got := server.received()
+	if len(got) != 1 {
+		t.Fatalf("Received %d emails, want 1", len(got))
+	}
+	m := got[0]
+	if !m.TLS || m.Auth == "" || m.From != "nightly@example.com" || strings.Join(m.To, ",") != "jane@example.com,joe@example.com" {
+		t.Errorf("Email was sent with TLS %v, auth %q, from %q to %v", m.TLS, m.Auth, m.From, m.To)
+	}
+
+	subject, text, html := parseEmail(t, m.Data)
+	if subject != "Trending repositories for 2018-02-08" {
+		t.Errorf("Subject = %q", subject)
+	}
+	for _, want := range []string{"First time trending (2)", "acme/jet", "Screenshot: https://raw.githubusercontent.com/acme/rocket/master/screenshot.png", "Repeat performers (1)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Text part does not contain %q:\n%s", want, text)
+		}
+	}
+	for _, want := range []string{
+		`<a href="https://github.com/acme/rocket"><img src="https://raw.githubusercontent.com/acme/rocket/master/screenshot.png"`,
+		"Fast &amp; &lt;safe&gt; rockets",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML part does not contain %q:\n%s", want, html)
+		}
+	}
+}
+
+func TestEmail_Notify_Failure(t *testing.T) {
+	t.Parallel()
+
+	server := newSMTPServer(t, nil)
+	cfg, _ := LoadConfig(testEnv(nil))
+	e := &Email{Addr: server.Addr, From: "nightly@example.com", To: []string{"jane@example.com"}, Config: cfg}
+
+	err := e.Notify(context.Background(), &Summary{Date: "2018-02-08", Error: "upload failed"})
+	if err != nil {
+		t.Fatalf("Notify() failed with error: %v", err)
+	}
+	got := server.received()
+	if len(got) != 1 || got[0].TLS {
+		t.Fatalf("Received %v, want one email without TLS", got)
+	}
+	subject, text, _ := parseEmail(t, got[0].Data)
+	if subject != "Publishing trending repositories for 2018-02-08 failed" || text != "Publishing trending repositories for 2018-02-08 failed: upload failed" {
+		t.Errorf("Email has subject %q and text %q", subject, text)
+	}
+}
+
+func TestEmail_Notify_StartTLSRequired(t *testing.T) {
+	t.Parallel()
+
+	server := newSMTPServer(t, nil)
+	cfg, _ := LoadConfig(testEnv(nil))
+	e := &Email{Addr: server.Addr, From: "nightly@example.com", To: []string{"jane@example.com"}, StartTLS: true, Config: cfg}
+
+	err := e.Notify(context.Background(), testSummary())
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Errorf("Notify() error = %v, want STARTTLS not supported", err)
+	}
+	if len(server.received()) != 0 {
+		t.Errorf("Email was sent without STARTTLS")
+	}
+}
+
+func TestLoadConfig_SMTP(t *testing.T) {
+	tests := []struct {
+		name    string
+		vars    map[string]string
+		wantErr bool
+	}{
+		{"OK", map[string]string{"NOTIFY_SMTP_ADDR": "smtp.example.com:587", "NOTIFY_SMTP_FROM": "a@example.com", "NOTIFY_SMTP_TO": "b@example.com, c@example.com"}, false},
+		{"No port", map[string]string{"NOTIFY_SMTP_ADDR": "smtp.example.com", "NOTIFY_SMTP_FROM": "a@example.com", "NOTIFY_SMTP_TO": "b@example.com"}, true},
+		{"No recipients", map[string]string{"NOTIFY_SMTP_ADDR": "smtp.example.com:587", "NOTIFY_SMTP_FROM": "a@example.com"}, true},
+		{"No sender", map[string]string{"NOTIFY_SMTP_ADDR": "smtp.example.com:587", "NOTIFY_SMTP_TO": "b@example.com"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadConfig(testEnv(tt.vars))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(cfg.SMTPTo) != 2 || New(cfg, nil) == nil {
+				t.Errorf("LoadConfig() = %+v, want two recipients and an email notifier", cfg)
+			}
+		})
+	}
+}
+
+func TestNew_FailuresOnlyEmail(t *testing.T) {
+	t.Parallel()
+
+	hooks := newHookServer(t)
+	server := newSMTPServer(t, nil)
+	cfg, err := LoadConfig(testEnv(map[string]string{
+		"NOTIFY_SLACK_URL":     hooks.URL + "/slack",
+		"NOTIFY_FAILURES_ONLY": "true",
+		"NOTIFY_SMTP_ADDR":     server.Addr,
+		"NOTIFY_SMTP_FROM":     "nightly@example.com",
+		"NOTIFY_SMTP_TO":       "jane@example.com",
+	}))
+	if err != nil {
+		t.Fatalf("LoadConfig() failed with error: %v", err)
+	}
+
+	err = New(cfg, hooks.Client()).Notify(context.Background(), testSummary())
+	if err != nil {
+		t.Fatalf("Notify() failed with error: %v", err)
+	}
+	if len(hooks.posted("/slack")) != 0 {
+		t.Errorf("Successful runs should not be posted to Slack with NOTIFY_FAILURES_ONLY")
+	}
+	if len(server.received()) != 1 {
+		t.Errorf("The email digest should be sent for successful runs with NOTIFY_FAILURES_ONLY")
+	}
+}
@@ -0,0 +1,264 @@
+// Package notify sends a summary of each run to webhooks, chat services and
+// email recipients.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/quasoft/changelog-nightly-parser/github"
+	"github.com/quasoft/changelog-nightly-parser/internal/env"
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+// topReposCount is the number of repositories listed in a summary.
+const topReposCount = 5
+
+const (
+	// DefaultTemplate is the template of the chat messages sent after a successful run.
+	DefaultTemplate = `Trending repositories for {{.Date}}: {{.Total}} ({{.FirstTimers}} first timers, {{.TopNew}} top new, {{.RepeatPerformers}} repeat performers)
+{{range .TopRepos}}- {{.Name}} ({{.Stars}} stars): {{.URL}}
+{{end}}{{if .FileURL}}{{.FileURL}}{{end}}`
+	// DefaultFailureTemplate is the template of the chat messages sent after a failed run.
+	DefaultFailureTemplate = `Publishing trending repositories for {{.Date}} failed: {{.Error}}`
+)
+
+// Summary describes the result of a run.
+type Summary struct {
+	Date             string `json:"Date"`
+	FirstTimers      int    `json:"FirstTimers"`
+	TopNew           int    `json:"TopNew"`
+	RepeatPerformers int    `json:"RepeatPerformers"`
+	Total            int    `json:"Total"`
+	// TopRepos are the repositories that gained the most stars on the day.
+	TopRepos []nightly.Repository `json:"TopRepos"`
+	// FileURL is the link to the committed daily file, or to the pull request.
+	FileURL string `json:"FileURL"`
+	// Error is the reason the run failed, or empty if it succeeded.
+	Error string `json:"Error"`
+	// Trending are all repos of the day, for notifiers listing them in full.
+	Trending *nightly.TrendingRepos `json:"-"`
+}
+
+// NewSummary returns the summary of the trending repos of the day.
+func NewSummary(date string, trending *nightly.TrendingRepos) *Summary {
+	s := &Summary{
+		Date:             date,
+		FirstTimers:      len(trending.First),
+		TopNew:           len(trending.New),
+		RepeatPerformers: len(trending.Repeaters),
+		Total:            trending.Count(),
+		TopRepos:         []nightly.Repository{},
+		Trending:         trending,
+	}
+
+	seen := map[string]bool{}
+	for _, repos := range [][]nightly.Repository{trending.First, trending.New, trending.Repeaters} {
+		for _, r := range repos {
+			if u := r.CanonicalURL(); !seen[u] {
+				seen[u] = true
+				s.TopRepos = append(s.TopRepos, r)
+			}
+		}
+	}
+	sort.SliceStable(s.TopRepos, func(i, j int) bool {
+		return s.TopRepos[i].NewStars > s.TopRepos[j].NewStars
+	})
+	if len(s.TopRepos) > topReposCount {
+		s.TopRepos = s.TopRepos[:topReposCount]
+	}
+	return s
+}
+
+// Failed reports whether the run failed.
+func (s *Summary) Failed() bool {
+	return s.Error != ""
+}
+
+// Notifier sends the summary of a run somewhere. Requests are sent with the
+// given context, so that they are cancelled with the run.
+type Notifier interface {
+	Notify(ctx context.Context, s *Summary) error
+}
+
+// Multi sends the summary to all notifiers, even if some of them fail.
+type Multi []Notifier
+
+// Notify sends the summary to all notifiers. Returns an error listing all
+// notifiers that failed.
+func (m Multi) Notify(ctx context.Context, s *Summary) error {
+	errs := []string{}
+	for _, n := range m {
+		err := n.Notify(ctx, s)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("Notification failed: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Config contains the notification settings. See LoadConfig() for the
+// environment variables it is read from.
+type Config struct {
+	WebhookURL string
+	SlackURL   string
+	DiscordURL string
+	// Template and FailureTemplate render chat messages after successful and
+	// failed runs, with the Summary as data.
+	Template        *template.Template
+	FailureTemplate *template.Template
+	// FailuresOnly disables webhook and chat notifications after successful runs.
+	// The email digest is sent after every run regardless.
+	FailuresOnly bool
+
+	// SMTP settings of the email digest, sent only if SMTPAddr is set.
+	SMTPAddr     string
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+	SMTPTo       []string
+	// SMTPStartTLS requires the SMTP server to support STARTTLS. STARTTLS is
+	// used whenever the server supports it, even if not required.
+	SMTPStartTLS bool
+}
+
+// LoadConfig reads the notification settings with the getenv function from the
+// following environment variables, all optional:
+// - NOTIFY_WEBHOOK_URL - URL to post the summary to, as JSON
+// - NOTIFY_SLACK_URL - Slack-compatible incoming webhook URL
+// - NOTIFY_DISCORD_URL - Discord-compatible webhook URL
+// - NOTIFY_TEMPLATE - template of chat messages after successful runs (default: DefaultTemplate)
+// - NOTIFY_FAILURE_TEMPLATE - template of chat messages after failed runs (default: DefaultFailureTemplate)
+// - NOTIFY_FAILURES_ONLY - only post to webhooks and chats about failed runs, if "true" (the email digest is always sent)
+// - NOTIFY_SMTP_ADDR - host:port of the SMTP server to send the email digest through
+// - NOTIFY_SMTP_USERNAME, NOTIFY_SMTP_PASSWORD - SMTP credentials, if the server requires authentication
+// - NOTIFY_SMTP_FROM - sender address of the email digest, required with NOTIFY_SMTP_ADDR
+// - NOTIFY_SMTP_TO - recipient addresses, separated by ",", required with NOTIFY_SMTP_ADDR
+// - NOTIFY_SMTP_STARTTLS - fail instead of sending unencrypted if the server does not support STARTTLS, if "true"
+func LoadConfig(getenv func(string) string) (*Config, error) {
+	cfg := &Config{
+		WebhookURL:   getenv("NOTIFY_WEBHOOK_URL"),
+		SlackURL:     getenv("NOTIFY_SLACK_URL"),
+		DiscordURL:   getenv("NOTIFY_DISCORD_URL"),
+		SMTPAddr:     getenv("NOTIFY_SMTP_ADDR"),
+		SMTPUsername: getenv("NOTIFY_SMTP_USERNAME"),
+		SMTPPassword: getenv("NOTIFY_SMTP_PASSWORD"),
+		SMTPFrom:     getenv("NOTIFY_SMTP_FROM"),
+	}
+	var err error
+	cfg.FailuresOnly, err = env.Bool(getenv, "NOTIFY_FAILURES_ONLY")
+	if err != nil {
+		return nil, err
+	}
+	cfg.SMTPStartTLS, err = env.Bool(getenv, "NOTIFY_SMTP_STARTTLS")
+	if err != nil {
+		return nil, err
+	}
+	for _, to := range strings.Split(getenv("NOTIFY_SMTP_TO"), ",") {
+		if to = strings.TrimSpace(to); to != "" {
+			cfg.SMTPTo = append(cfg.SMTPTo, to)
+		}
+	}
+	if cfg.SMTPAddr != "" {
+		if _, _, err := net.SplitHostPort(cfg.SMTPAddr); err != nil {
+			return nil, fmt.Errorf("Invalid NOTIFY_SMTP_ADDR %q, expected host:port", cfg.SMTPAddr)
+		}
+		if cfg.SMTPFrom == "" || len(cfg.SMTPTo) == 0 {
+			return nil, fmt.Errorf("Both NOTIFY_SMTP_FROM and NOTIFY_SMTP_TO have to be specified with NOTIFY_SMTP_ADDR")
+		}
+	}
+
+	cfg.Template, err = parseTemplate("NOTIFY_TEMPLATE", getenv("NOTIFY_TEMPLATE"), DefaultTemplate)
+	if err != nil {
+		return nil, err
+	}
+	cfg.FailureTemplate, err = parseTemplate("NOTIFY_FAILURE_TEMPLATE", getenv("NOTIFY_FAILURE_TEMPLATE"), DefaultFailureTemplate)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// parseTemplate() parses the template in text, or def if text is empty.
+func parseTemplate(name string, text string, def string) (*template.Template, error) {
+	if text == "" {
+		text = def
+	}
+	t, err := template.New(name).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %s template: %v", name, err)
+	}
+	return t, nil
+}
+
+// message() renders the chat message for the summary.
+func (cfg *Config) message(s *Summary) (string, error) {
+	t := cfg.Template
+	if s.Failed() {
+		t = cfg.FailureTemplate
+	}
+
+	var buf bytes.Buffer
+	err := t.Execute(&buf, s)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// New returns a notifier sending to all configured webhooks with the client and
+// emailing the digest if SMTP is configured, or nil if nothing is configured.
+// With FailuresOnly, webhooks are only notified about failed runs.
+func New(cfg *Config, client github.Doer) Notifier {
+	if cfg == nil {
+		return nil
+	}
+	m := Multi{}
+	if cfg.WebhookURL != "" {
+		m = append(m, &Webhook{URL: cfg.WebhookURL, Client: client, Config: cfg})
+	}
+	if cfg.SlackURL != "" {
+		m = append(m, &Slack{URL: cfg.SlackURL, Client: client, Config: cfg})
+	}
+	if cfg.DiscordURL != "" {
+		m = append(m, &Discord{URL: cfg.DiscordURL, Client: client, Config: cfg})
+	}
+	if cfg.FailuresOnly && len(m) > 0 {
+		m = Multi{failuresOnly{m}}
+	}
+	if cfg.SMTPAddr != "" {
+		m = append(m, &Email{
+			Addr:     cfg.SMTPAddr,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+			To:       cfg.SMTPTo,
+			StartTLS: cfg.SMTPStartTLS,
+			Config:   cfg,
+		})
+	}
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+// failuresOnly is a notifier that only notifies about failed runs.
+type failuresOnly struct {
+	next Notifier
+}
+
+func (f failuresOnly) Notify(ctx context.Context, s *Summary) error {
+	if !s.Failed() {
+		return nil
+	}
+	return f.next.Notify(ctx, s)
+}
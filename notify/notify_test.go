@@ -0,0 +1,203 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+// hookServer is a stand-in for webhook endpoints, recording the JSON bodies
+// posted to each path.
+type hookServer struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	bodies map[string][]map[string]interface{}
+	// fail makes requests to these paths fail with status 500.
+	fail map[string]bool
+}
+
+func newHookServer(t *testing.T) *hookServer {
+	s := &hookServer{bodies: map[string][]map[string]interface{}{}, fail: map[string]bool{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		s.bodies[r.URL.Path] = append(s.bodies[r.URL.Path], body)
+		if s.fail[r.URL.Path] {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *hookServer) posted(path string) []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bodies[path]
+}
+
+func testEnv(vars map[string]string) func(string) string {
+	return func(key string) string {
+		return vars[key]
+	}
+}
+
+func testSummary() *Summary {
+	return NewSummary("2018-02-08", &nightly.TrendingRepos{
+		First: []nightly.Repository{
+			{Name: "acme/rocket", URL: "https://github.com/acme/rocket", Stars: 100, NewStars: 10},
+			{Name: "acme/jet", URL: "https://github.com/acme/jet", Stars: 50, NewStars: 40},
+		},
+		Repeaters: []nightly.Repository{{Name: "acme/rocket", URL: "https://github.com/acme/rocket", Stars: 100, NewStars: 10}},
+	})
+}
+
+func TestNewSummary(t *testing.T) {
+	s := testSummary()
+	if s.FirstTimers != 2 || s.RepeatPerformers != 1 || s.Total != 3 {
+		t.Errorf("NewSummary() = %+v", s)
+	}
+	if len(s.TopRepos) != 2 || s.TopRepos[0].Name != "acme/jet" || s.TopRepos[1].Name != "acme/rocket" {
+		t.Errorf("NewSummary() top repos = %+v, want acme/jet and acme/rocket once", s.TopRepos)
+	}
+}
+
+func TestNew(t *testing.T) {
+	server := newHookServer(t)
+	cfg, err := LoadConfig(testEnv(map[string]string{
+		"NOTIFY_WEBHOOK_URL": server.URL + "/webhook",
+		"NOTIFY_SLACK_URL":   server.URL + "/slack",
+		"NOTIFY_DISCORD_URL": server.URL + "/discord",
+		"NOTIFY_TEMPLATE":    "{{.Total}} repos for {{.Date}} at {{.FileURL}}",
+	}))
+	if err != nil {
+		t.Fatalf("LoadConfig() failed with error: %v", err)
+	}
+
+	s := testSummary()
+	s.FileURL = "https://github.com/user/trending-daily/blob/master/2018-02-08.json"
+	err = New(cfg, server.Client()).Notify(context.Background(), s)
+	if err != nil {
+		t.Fatalf("Notify() failed with error: %v", err)
+	}
+
+	want := "3 repos for 2018-02-08 at https://github.com/user/trending-daily/blob/master/2018-02-08.json"
+	if got := server.posted("/slack"); len(got) != 1 || got[0]["text"] != want {
+		t.Errorf("Slack message = %v, want %q", got, want)
+	}
+	if got := server.posted("/discord"); len(got) != 1 || got[0]["content"] != want {
+		t.Errorf("Discord message = %v, want %q", got, want)
+	}
+	got := server.posted("/webhook")
+	if len(got) != 1 || got[0]["Status"] != "success" || got[0]["Total"] != float64(3) || got[0]["Message"] != want {
+		t.Errorf("Webhook body = %v", got)
+	}
+}
+
+func TestNew_Failure(t *testing.T) {
+	server := newHookServer(t)
+	cfg, err := LoadConfig(testEnv(map[string]string{
+		"NOTIFY_SLACK_URL":     server.URL + "/slack",
+		"NOTIFY_FAILURES_ONLY": "true",
+	}))
+	if err != nil {
+		t.Fatalf("LoadConfig() failed with error: %v", err)
+	}
+	n := New(cfg, server.Client())
+
+	err = n.Notify(context.Background(), testSummary())
+	if err != nil || len(server.posted("/slack")) != 0 {
+		t.Errorf("Successful runs should not be notified with NOTIFY_FAILURES_ONLY, error: %v", err)
+	}
+
+	err = n.Notify(context.Background(), &Summary{Date: "2018-02-08", Error: "upload failed"})
+	if err != nil {
+		t.Fatalf("Notify() failed with error: %v", err)
+	}
+	got := server.posted("/slack")
+	if len(got) != 1 || got[0]["text"] != "Publishing trending repositories for 2018-02-08 failed: upload failed" {
+		t.Errorf("Slack message = %v", got)
+	}
+}
+
+func TestMulti_Notify(t *testing.T) {
+	server := newHookServer(t)
+	server.fail["/slack"] = true
+	cfg, _ := LoadConfig(testEnv(nil))
+	m := Multi{
+		&Slack{URL: server.URL + "/slack", Client: server.Client(), Config: cfg},
+		&Discord{URL: server.URL + "/discord", Client: server.Client(), Config: cfg},
+	}
+
+	err := m.Notify(context.Background(), testSummary())
+	if err == nil || !strings.Contains(err.Error(), "status 500") {
+		t.Errorf("Notify() error = %v, want the Slack failure", err)
+	}
+	if len(server.posted("/discord")) != 1 {
+		t.Errorf("Discord should be notified even if Slack fails")
+	}
+}
+
+func TestSlack_Notify_Canceled(t *testing.T) {
+	server := newHookServer(t)
+	cfg, _ := LoadConfig(testEnv(nil))
+	s := &Slack{URL: server.URL + "/slack", Client: server.Client(), Config: cfg}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.Notify(ctx, testSummary()); err == nil {
+		t.Errorf("Notify() should fail with a canceled context")
+	}
+	if len(server.posted("/slack")) != 0 {
+		t.Errorf("Slack was notified with a canceled context")
+	}
+}
+
+func TestDiscord_Truncate(t *testing.T) {
+	server := newHookServer(t)
+	cfg, _ := LoadConfig(testEnv(map[string]string{"NOTIFY_TEMPLATE": strings.Repeat("x", 3000)}))
+	d := &Discord{URL: server.URL + "/discord", Client: server.Client(), Config: cfg}
+
+	err := d.Notify(context.Background(), testSummary())
+	if err != nil {
+		t.Fatalf("Notify() failed with error: %v", err)
+	}
+	got := server.posted("/discord")
+	if len(got) != 1 || len([]rune(got[0]["content"].(string))) != discordMaxLength {
+		t.Errorf("Discord message was not truncated to %d characters", discordMaxLength)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := LoadConfig(testEnv(nil))
+	if err != nil {
+		t.Fatalf("LoadConfig() failed with error: %v", err)
+	}
+	if New(cfg, http.DefaultClient) != nil {
+		t.Errorf("New() should return nil without any webhooks configured")
+	}
+
+	_, err = LoadConfig(testEnv(map[string]string{"NOTIFY_TEMPLATE": "{{.Total"}))
+	if err == nil {
+		t.Errorf("LoadConfig() should have failed for an invalid template")
+	}
+
+	for _, key := range []string{"NOTIFY_FAILURES_ONLY", "NOTIFY_SMTP_STARTTLS"} {
+		_, err = LoadConfig(testEnv(map[string]string{key: "yes"}))
+		if err == nil || !strings.Contains(err.Error(), key) {
+			t.Errorf("LoadConfig() error = %v, want an invalid %s", err, key)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/quasoft/changelog-nightly-parser/github"
+)
+
+// post() sends the JSON encoding of body to the URL with the context and checks
+// that the response status is 2xx.
+func post(ctx context.Context, client github.Doer, u string, body interface{}) error {
+	j, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	r, err := http.NewRequestWithContext(ctx, "POST", u, bytes.NewReader(j))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := client.Do(r)
+	if err != nil {
+		return fmt.Errorf("POST to %s failed with error: %v", r.URL.Host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("POST to %s failed with status %d, msg: %s", r.URL.Host, resp.StatusCode, string(msg))
+	}
+	return nil
+}
+
+// Webhook posts the summary as JSON, together with the rendered message, to a URL.
+type Webhook struct {
+	URL    string
+	Client github.Doer
+	Config *Config
+}
+
+// Notify posts the summary to the webhook.
+func (w *Webhook) Notify(ctx context.Context, s *Summary) error {
+	msg, err := w.Config.message(s)
+	if err != nil {
+		return err
+	}
+
+	status := "success"
+	if s.Failed() {
+		status = "failure"
+	}
+	return post(ctx, w.Client, w.URL, struct {
+		*Summary
+		Status  string `json:"Status"`
+		Message string `json:"Message"`
+	}{s, status, msg})
+}
+
+// Slack posts the rendered message to a Slack-compatible incoming webhook.
+type Slack struct {
+	URL    string
+	Client github.Doer
+	Config *Config
+}
+
+// Notify posts the message to the incoming webhook.
+func (sl *Slack) Notify(ctx context.Context, s *Summary) error {
+	msg, err := sl.Config.message(s)
+	if err != nil {
+		return err
+	}
+	return post(ctx, sl.Client, sl.URL, map[string]string{"text": msg})
+}
+
+// Discord posts the rendered message to a Discord-compatible webhook.
+type Discord struct {
+	URL    string
+	Client github.Doer
+	Config *Config
+}
+
+// discordMaxLength is the maximum length of the content of a Discord message.
+const discordMaxLength = 2000
+
+// Notify posts the message to the webhook, truncated to the maximum length
+// Discord accepts.
+func (d *Discord) Notify(ctx context.Context, s *Summary) error {
+	msg, err := d.Config.message(s)
+	if err != nil {
+		return err
+	}
+	if runes := []rune(msg); len(runes) > discordMaxLength {
+		msg = string(runes[:discordMaxLength-1]) + "…"
+	}
+	return post(ctx, d.Client, d.URL, map[string]string{"content": msg})
+}
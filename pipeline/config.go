@@ -0,0 +1,464 @@
+package pipeline
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/filter"
+	"github.com/quasoft/changelog-nightly-parser/github"
+	"github.com/quasoft/changelog-nightly-parser/internal/env"
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+	"github.com/quasoft/changelog-nightly-parser/notify"
+)
+
+const (
+	defaultCommitterName   = "Bot"
+	defaultCommitterEmail  = "bot@example.com"
+	defaultMessageTemplate = "Uploading trending repos for {{.Date}}{{if .Diff}}\n\n{{.Diff}}{{end}}"
+	defaultPathTemplate    = "{{.Date}}.json"
+	defaultPullBranch      = "trending/{{.Date}}"
+	defaultMergeMethod     = "merge"
+	defaultOTLPEndpoint    = "http://localhost:4318"
+	defaultServiceName     = "changelog-nightly-parser"
+)
+
+// Config contains the settings used for downloading the nightly pages and for
+// committing files to the Github repository. See LoadConfig() for the environment
+// variables it is read from.
+type Config struct {
+	Owner      string
+	Repository string
+	// Token is the personal access token, used if App is nil.
+	Token string
+	// App contains the settings for authenticating as a Github App installation.
+	App *AppConfig
+
+	// UploadAPI is "contents" for uploading files one by one with the Contents API,
+	// or empty for committing all files at once with the Git Data API.
+	UploadAPI string
+
+	// Branch to commit to. The default branch of the repository, if empty.
+	Branch string
+
+	Committer github.Identity
+	// Author of the commits. Github uses the committer, if nil.
+	Author *github.Identity
+	// CoAuthors are added as "Co-authored-by" trailers to the commit message.
+	CoAuthors []github.Identity
+
+	MessageTemplate *template.Template
+	PathTemplate    *template.Template
+
+	// PullRequest enables committing to a new branch named by PullBranchTemplate
+	// and opening a pull request to Branch, instead of committing to Branch directly.
+	PullRequest        bool
+	PullBranchTemplate *template.Template
+	PullLabels         []string
+	// AutoMerge merges the pull request right after opening it, using MergeMethod
+	// ("merge", "squash" or "rebase").
+	AutoMerge   bool
+	MergeMethod string
+
+	// Day decides which day of Changelog Nightly is processed.
+	Day DayPolicy
+	// SourceBaseURL is the site the pages are downloaded from, and SourcePathTemplate
+	// the path of the page of a day, relative to it.
+	SourceBaseURL      *url.URL
+	SourcePathTemplate *template.Template
+	// CacheDir is the directory nightly pages and readmes are cached in, if not empty.
+	CacheDir string
+	// Enrich enables adding the metadata of each repository from the Github API.
+	Enrich bool
+	// RecordDir is the directory nightly pages and readmes are recorded to, if not
+	// empty, for replaying them later with archive.Replayer.
+	RecordDir string
+	// LookupAPI is "rest" for looking up readmes and metadata repository by repository,
+	// or empty for batching the lookups in GraphQL queries, falling back to REST.
+	LookupAPI string
+	// StoreFile is the file of the embedded store, in which the repositories of
+	// every processed day are kept, if not empty.
+	StoreFile string
+	// Diff enables comparing each day with the previous day published, in a
+	// JSON file next to the daily file and in the pull request body.
+	Diff bool
+	// Filter are the rules repositories have to meet to be published, if not nil.
+	Filter *filter.Rules
+	// Dedup enables merging repositories listed more than once into a single
+	// entry, which lists all categories the repository appeared in.
+	Dedup bool
+	// Notify are the webhooks the summary of each run is sent to.
+	Notify *notify.Config
+	// LogLevel is the minimum level of the records logged, and LogFormat is
+	// "json" for JSON lines, "text" for key=value lines or empty for the default
+	// of the command (JSON in Lambda, text otherwise).
+	LogLevel  slog.Level
+	LogFormat string
+	// Metrics is "emf" for printing CloudWatch Embedded Metric Format records to
+	// stdout, "prometheus" for writing the Prometheus text format to MetricsFile,
+	// or empty for not recording metrics. MetricsNamespace is the CloudWatch
+	// namespace of EMF metrics.
+	Metrics          string
+	MetricsFile      string
+	MetricsNamespace string
+	// TraceExporter is "stdout" for printing spans to stdout, "otlp" for sending
+	// them to the OTLP/HTTP collector at OTLPEndpoint, or empty for not tracing.
+	// ServiceName identifies the spans of the pipeline in the collector.
+	TraceExporter string
+	OTLPEndpoint  string
+	ServiceName   string
+}
+
+// AppConfig identifies a Github App installation and contains the private
+// key of the App.
+type AppConfig struct {
+	ID             string
+	InstallationID string
+	Key            *rsa.PrivateKey
+}
+
+// CommitData is the data available to the message and path templates, e.g.
+// "Trending repos for {{.Date}} ({{.Total}} repos)" or "{{.Year}}/{{.Month}}/{{.Date}}.json".
+type CommitData struct {
+	Date             string
+	Year             string
+	Month            string
+	Day              string
+	FirstTimers      int
+	TopNew           int
+	RepeatPerformers int
+	Total            int
+	// Diff is the Markdown comparison with the previous day, if enabled and
+	// a previous day has been published.
+	Diff string
+}
+
+// newCommitData() returns the template data for the trending repos of the given day.
+func newCommitData(trending *nightly.TrendingRepos, t time.Time) CommitData {
+	return CommitData{
+		Date:             t.Format("2006-01-02"),
+		Year:             t.Format("2006"),
+		Month:            t.Format("01"),
+		Day:              t.Format("02"),
+		FirstTimers:      len(trending.First),
+		TopNew:           len(trending.New),
+		RepeatPerformers: len(trending.Repeaters),
+		Total:            trending.Count(),
+	}
+}
+
+// LoadConfig reads the upload settings with the getenv function (usually
+// os.Getenv) from the following environment variables:
+// - GITHUB_OWNER, GITHUB_REPOSITORY, GITHUB_TOKEN - required, see package documentation
+// - GITHUB_APP_ID, GITHUB_APP_INSTALLATION_ID - authenticate as a Github App installation instead of with GITHUB_TOKEN
+// - GITHUB_APP_PRIVATE_KEY or GITHUB_APP_PRIVATE_KEY_FILE - PEM encoded private key of the Github App
+// - GITHUB_UPLOAD_API - "contents" to upload files one by one, instead of in a single commit
+// - GITHUB_BRANCH - branch to commit to (default: the default branch of the repository)
+// - GITHUB_COMMITTER_NAME, GITHUB_COMMITTER_EMAIL - committer (default: "Bot", "bot@example.com")
+// - GITHUB_AUTHOR_NAME, GITHUB_AUTHOR_EMAIL - author (default: the committer)
+// - GITHUB_CO_AUTHORS - co-authors separated by ";" (eg. "Jane <jane@example.com>; Joe <joe@example.com>")
+// - GITHUB_COMMIT_MESSAGE - template of the commit message (default: "Uploading trending repos for {{.Date}}",
+// followed by the diff if NIGHTLY_DIFF is enabled)
+// - GITHUB_PATH_TEMPLATE - template of the daily file path (default: "{{.Date}}.json")
+// - GITHUB_PULL_REQUEST - open a pull request instead of committing directly, if "true"
+// - GITHUB_PULL_BRANCH - template of the pull request branch, depending only on the date (default: "trending/{{.Date}}")
+// - GITHUB_PULL_LABELS - labels to add to the pull request, separated by ","
+// - GITHUB_PULL_AUTO_MERGE - merge the pull request after opening it, if "true"
+// - GITHUB_PULL_MERGE_METHOD - "merge", "squash" or "rebase" (default: "merge")
+// - NIGHTLY_* - source settings, see LoadSourceConfig()
+// - NOTIFY_* - notification settings, see notify.LoadConfig()
+func LoadConfig(getenv func(string) string) (*Config, error) {
+	cfg := Config{
+		Owner:      getenv("GITHUB_OWNER"),
+		Repository: getenv("GITHUB_REPOSITORY"),
+		Branch:     getenv("GITHUB_BRANCH"),
+		UploadAPI:  getenv("GITHUB_UPLOAD_API"),
+		Committer: github.Identity{
+			Name:  envOrDefault(getenv, "GITHUB_COMMITTER_NAME", defaultCommitterName),
+			Email: envOrDefault(getenv, "GITHUB_COMMITTER_EMAIL", defaultCommitterEmail),
+		},
+	}
+
+	if cfg.Owner == "" {
+		return nil, fmt.Errorf("Upload GitHub owner not specified")
+	}
+	if cfg.Repository == "" {
+		return nil, fmt.Errorf("Upload GitHub repository not specified")
+	}
+
+	if appID := getenv("GITHUB_APP_ID"); appID != "" {
+		installationID := getenv("GITHUB_APP_INSTALLATION_ID")
+		if installationID == "" {
+			return nil, fmt.Errorf("GitHub App installation ID not specified")
+		}
+		keyPEM, err := github.ReadPrivateKey(getenv("GITHUB_APP_PRIVATE_KEY"), getenv("GITHUB_APP_PRIVATE_KEY_FILE"))
+		if err != nil {
+			return nil, err
+		}
+		key, err := github.ParsePrivateKey(keyPEM)
+		if err != nil {
+			return nil, err
+		}
+		cfg.App = &AppConfig{ID: appID, InstallationID: installationID, Key: key}
+	} else {
+		cfg.Token = getenv("GITHUB_TOKEN")
+		if cfg.Token == "" {
+			return nil, fmt.Errorf("Upload GitHub token not specified")
+		}
+	}
+
+	authorName := getenv("GITHUB_AUTHOR_NAME")
+	authorEmail := getenv("GITHUB_AUTHOR_EMAIL")
+	if authorName != "" || authorEmail != "" {
+		if authorName == "" || authorEmail == "" {
+			return nil, fmt.Errorf("Both GITHUB_AUTHOR_NAME and GITHUB_AUTHOR_EMAIL have to be specified")
+		}
+		cfg.Author = &github.Identity{Name: authorName, Email: authorEmail}
+	}
+
+	for _, s := range strings.Split(getenv("GITHUB_CO_AUTHORS"), ";") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		identity, err := parseIdentity(s)
+		if err != nil {
+			return nil, err
+		}
+		cfg.CoAuthors = append(cfg.CoAuthors, identity)
+	}
+
+	var err error
+	cfg.MessageTemplate, err = template.New("message").Parse(envOrDefault(getenv, "GITHUB_COMMIT_MESSAGE", defaultMessageTemplate))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid GITHUB_COMMIT_MESSAGE template: %v", err)
+	}
+	cfg.PathTemplate, err = template.New("path").Parse(envOrDefault(getenv, "GITHUB_PATH_TEMPLATE", defaultPathTemplate))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid GITHUB_PATH_TEMPLATE template: %v", err)
+	}
+
+	cfg.PullRequest, err = env.Bool(getenv, "GITHUB_PULL_REQUEST")
+	if err != nil {
+		return nil, err
+	}
+	cfg.PullBranchTemplate, err = template.New("branch").Parse(envOrDefault(getenv, "GITHUB_PULL_BRANCH", defaultPullBranch))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid GITHUB_PULL_BRANCH template: %v", err)
+	}
+	for _, label := range strings.Split(getenv("GITHUB_PULL_LABELS"), ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			cfg.PullLabels = append(cfg.PullLabels, label)
+		}
+	}
+	cfg.AutoMerge, err = env.Bool(getenv, "GITHUB_PULL_AUTO_MERGE")
+	if err != nil {
+		return nil, err
+	}
+	cfg.MergeMethod = envOrDefault(getenv, "GITHUB_PULL_MERGE_METHOD", defaultMergeMethod)
+	if cfg.UploadAPI != "" && cfg.UploadAPI != "contents" && cfg.UploadAPI != "git" {
+		return nil, fmt.Errorf("Invalid GITHUB_UPLOAD_API %q, expected git or contents", cfg.UploadAPI)
+	}
+	if cfg.MergeMethod != "merge" && cfg.MergeMethod != "squash" && cfg.MergeMethod != "rebase" {
+		return nil, fmt.Errorf("Invalid GITHUB_PULL_MERGE_METHOD %q, expected merge, squash or rebase", cfg.MergeMethod)
+	}
+
+	err = loadSourceConfig(getenv, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Notify, err = notify.LoadConfig(getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// LoadSourceConfig reads only the settings for downloading and processing the
+// nightly pages, without any upload settings, for runs that don't publish to
+// Github (eg. replaying archived pages). The settings are read with the getenv
+// function from the following environment variables:
+// - NIGHTLY_BASE_URL - site to download the pages from (default: "https://nightly.changelog.com/")
+// - NIGHTLY_PATH_TEMPLATE - template of the page path, relative to NIGHTLY_BASE_URL (default: "{{.Year}}/{{.Month}}/{{.Day}}")
+// - NIGHTLY_CACHE_DIR - cache downloaded pages and readmes in this directory (eg. "/tmp/cache" in Lambda)
+// - NIGHTLY_RECORD_DIR - record downloaded pages and readmes to this directory, for replaying them later
+// - NIGHTLY_ENRICH - add topics, license, forks and other metadata from the Github API, if "true"
+// - NIGHTLY_FILTER_FILE - JSON file with the rules repositories have to meet to be published, see filter.Rules
+// - NIGHTLY_DEDUP - merge repositories listed more than once (in any category, with any URL variant), if "true"
+// - NIGHTLY_DIFF - compare each day with the previous day published, if "true" (ignored when replaying)
+// - NIGHTLY_STORE_FILE - keep the repositories of every processed day in this embedded database file
+// - NIGHTLY_LOOKUP_API - "rest" to look up readmes and metadata one repository at a time (default: "graphql", with a REST fallback)
+// - NIGHTLY_TIME_ZONE - IANA time zone in which days are counted (default: "UTC")
+// - NIGHTLY_CUTOFF_HOUR - hour (0-23) after which yesterday's page is expected to be published (default: 0)
+// - LOG_LEVEL - "debug", "info", "warn" or "error" (default: "info")
+// - LOG_FORMAT - "json" or "text" (default: "json" in Lambda, "text" on the command line)
+// - METRICS - "emf" to print CloudWatch Embedded Metric Format records to stdout, "prometheus" to write METRICS_FILE
+// - METRICS_FILE - file the Prometheus text format is written to after each run, required with "prometheus"
+// - METRICS_NAMESPACE - CloudWatch namespace of EMF metrics (default: "ChangelogNightly")
+// - OTEL_TRACES_EXPORTER - "stdout" to print the spans of each run to stdout, "otlp" to send them to a collector
+// - OTEL_EXPORTER_OTLP_ENDPOINT - base URL of the OTLP/HTTP collector (default: "http://localhost:4318")
+// - OTEL_SERVICE_NAME - service name of the spans (default: "changelog-nightly-parser")
+func LoadSourceConfig(getenv func(string) string) (*Config, error) {
+	cfg := Config{}
+	err := loadSourceConfig(getenv, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// loadSourceConfig() reads the NIGHTLY_* settings into cfg.
+func loadSourceConfig(getenv func(string) string, cfg *Config) error {
+	cfg.CacheDir = getenv("NIGHTLY_CACHE_DIR")
+	cfg.RecordDir = getenv("NIGHTLY_RECORD_DIR")
+	cfg.StoreFile = getenv("NIGHTLY_STORE_FILE")
+	cfg.LookupAPI = getenv("NIGHTLY_LOOKUP_API")
+	if cfg.LookupAPI != "" && cfg.LookupAPI != "rest" && cfg.LookupAPI != "graphql" {
+		return fmt.Errorf("Invalid NIGHTLY_LOOKUP_API %q, expected graphql or rest", cfg.LookupAPI)
+	}
+	if level := getenv("LOG_LEVEL"); level != "" {
+		err := cfg.LogLevel.UnmarshalText([]byte(level))
+		if err != nil {
+			return fmt.Errorf("Invalid LOG_LEVEL %q, expected debug, info, warn or error", level)
+		}
+	}
+	cfg.LogFormat = getenv("LOG_FORMAT")
+	if cfg.LogFormat != "" && cfg.LogFormat != "json" && cfg.LogFormat != "text" {
+		return fmt.Errorf("Invalid LOG_FORMAT %q, expected json or text", cfg.LogFormat)
+	}
+	cfg.Metrics = getenv("METRICS")
+	cfg.MetricsFile = getenv("METRICS_FILE")
+	cfg.MetricsNamespace = getenv("METRICS_NAMESPACE")
+	if cfg.Metrics != "" && cfg.Metrics != "emf" && cfg.Metrics != "prometheus" {
+		return fmt.Errorf("Invalid METRICS %q, expected emf or prometheus", cfg.Metrics)
+	}
+	if cfg.Metrics == "prometheus" && cfg.MetricsFile == "" {
+		return fmt.Errorf("METRICS_FILE has to be specified with METRICS=prometheus")
+	}
+	cfg.TraceExporter = getenv("OTEL_TRACES_EXPORTER")
+	if cfg.TraceExporter == "none" {
+		cfg.TraceExporter = ""
+	}
+	if cfg.TraceExporter != "" && cfg.TraceExporter != "stdout" && cfg.TraceExporter != "otlp" {
+		return fmt.Errorf("Invalid OTEL_TRACES_EXPORTER %q, expected stdout, otlp or none", cfg.TraceExporter)
+	}
+	cfg.OTLPEndpoint = envOrDefault(getenv, "OTEL_EXPORTER_OTLP_ENDPOINT", defaultOTLPEndpoint)
+	endpoint, err := url.Parse(cfg.OTLPEndpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return fmt.Errorf("Invalid OTEL_EXPORTER_OTLP_ENDPOINT %q, expected an http or https URL", cfg.OTLPEndpoint)
+	}
+	cfg.ServiceName = envOrDefault(getenv, "OTEL_SERVICE_NAME", defaultServiceName)
+
+	cfg.Enrich, err = env.Bool(getenv, "NIGHTLY_ENRICH")
+	if err != nil {
+		return err
+	}
+	cfg.Diff, err = env.Bool(getenv, "NIGHTLY_DIFF")
+	if err != nil {
+		return err
+	}
+	cfg.Dedup, err = env.Bool(getenv, "NIGHTLY_DEDUP")
+	if err != nil {
+		return err
+	}
+	if file := getenv("NIGHTLY_FILTER_FILE"); file != "" {
+		cfg.Filter, err = filter.LoadFile(file)
+		if err != nil {
+			return fmt.Errorf("Invalid NIGHTLY_FILTER_FILE: %v", err)
+		}
+	}
+	cfg.SourceBaseURL, err = parseSourceBaseURL(envOrDefault(getenv, "NIGHTLY_BASE_URL", defaultSourceBaseURL))
+	if err != nil {
+		return err
+	}
+	cfg.SourcePathTemplate, err = template.New("source").Parse(envOrDefault(getenv, "NIGHTLY_PATH_TEMPLATE", defaultSourcePathTemplate))
+	if err != nil {
+		return fmt.Errorf("Invalid NIGHTLY_PATH_TEMPLATE template: %v", err)
+	}
+
+	cfg.Day.Location, err = time.LoadLocation(envOrDefault(getenv, "NIGHTLY_TIME_ZONE", "UTC"))
+	if err != nil {
+		return fmt.Errorf("Invalid NIGHTLY_TIME_ZONE: %v", err)
+	}
+	if v := getenv("NIGHTLY_CUTOFF_HOUR"); v != "" {
+		cfg.Day.CutoffHour, err = strconv.Atoi(v)
+		if err != nil || cfg.Day.CutoffHour < 0 || cfg.Day.CutoffHour > 23 {
+			return fmt.Errorf("Invalid NIGHTLY_CUTOFF_HOUR %q, expected an hour between 0 and 23", v)
+		}
+	}
+	return nil
+}
+
+// envOrDefault() returns the value of the environment variable, or def if the
+// variable is not set or empty.
+func envOrDefault(getenv func(string) string, key string, def string) string {
+	if v := getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// parseIdentity() parses an identity in the "Name <email>" format.
+func parseIdentity(s string) (github.Identity, error) {
+	s = strings.TrimSpace(s)
+	start := strings.LastIndex(s, "<")
+	if start <= 0 || !strings.HasSuffix(s, ">") {
+		return github.Identity{}, fmt.Errorf("Invalid identity %q, expected \"Name <email>\"", s)
+	}
+
+	return github.Identity{
+		Name:  strings.TrimSpace(s[:start]),
+		Email: strings.TrimSpace(s[start+1 : len(s)-1]),
+	}, nil
+}
+
+// message() renders the commit message for the given data, followed by a
+// "Co-authored-by" trailer for each co-author.
+func (cfg *Config) message(data CommitData) (string, error) {
+	var buf bytes.Buffer
+	err := cfg.MessageTemplate.Execute(&buf, data)
+	if err != nil {
+		return "", err
+	}
+
+	if len(cfg.CoAuthors) > 0 {
+		buf.WriteString("\n\n")
+		for _, c := range cfg.CoAuthors {
+			fmt.Fprintf(&buf, "Co-authored-by: %s <%s>\n", c.Name, c.Email)
+		}
+	}
+
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
+
+// path() renders the path of the daily file for the given data.
+func (cfg *Config) path(data CommitData) (string, error) {
+	return renderPath(cfg.PathTemplate, data)
+}
+
+// pullBranch() renders the name of the pull request branch for the given data.
+func (cfg *Config) pullBranch(data CommitData) (string, error) {
+	return renderPath(cfg.PullBranchTemplate, data)
+}
+
+// renderPath() executes a template producing a slash separated path, like a file
+// path or branch name, and returns an error if the result is empty.
+func renderPath(tmpl *template.Template, data CommitData) (string, error) {
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, data)
+	if err != nil {
+		return "", err
+	}
+
+	p := strings.Trim(strings.TrimSpace(buf.String()), "/")
+	if p == "" {
+		return "", fmt.Errorf("Template %q produced an empty path", tmpl.Name())
+	}
+	return p, nil
+}
@@ -0,0 +1,159 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := LoadConfig(testEnv(nil))
+	if err != nil {
+		t.Fatalf("LoadConfig() failed with error: %v", err)
+	}
+	if cfg.Branch != "" {
+		t.Errorf("cfg.Branch = %q, want empty", cfg.Branch)
+	}
+	if cfg.Committer.Name != defaultCommitterName || cfg.Committer.Email != defaultCommitterEmail {
+		t.Errorf("cfg.Committer = %v, want default committer", cfg.Committer)
+	}
+	if cfg.Author != nil {
+		t.Errorf("cfg.Author = %v, want nil", cfg.Author)
+	}
+
+	data := newCommitData(&nightly.TrendingRepos{}, time.Date(2018, 2, 8, 0, 0, 0, 0, time.UTC))
+	message, err := cfg.message(data)
+	if err != nil {
+		t.Fatalf("cfg.message() failed with error: %v", err)
+	}
+	if message != "Uploading trending repos for 2018-02-08" {
+		t.Errorf("cfg.message() = %q, want default message", message)
+	}
+	path, err := cfg.path(data)
+	if err != nil {
+		t.Fatalf("cfg.path() failed with error: %v", err)
+	}
+	if path != "2018-02-08.json" {
+		t.Errorf("cfg.path() = %q, want %q", path, "2018-02-08.json")
+	}
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		key   string
+		value string
+	}{
+		{"Author without email", "GITHUB_AUTHOR_NAME", "Jane"},
+		{"Co-author without email", "GITHUB_CO_AUTHORS", "Jane <jane@example.com>; Joe"},
+		{"Invalid message template", "GITHUB_COMMIT_MESSAGE", "Trending {{.Date"},
+		{"Invalid path template", "GITHUB_PATH_TEMPLATE", "{{.Date}.json"},
+		{"Invalid upload API", "GITHUB_UPLOAD_API", "ftp"},
+		{"Invalid boolean", "GITHUB_PULL_REQUEST", "maybe"},
+		{"Invalid merge method", "GITHUB_PULL_MERGE_METHOD", "octopus"},
+		{"Relative base URL", "NIGHTLY_BASE_URL", "nightly.changelog.com"},
+		{"Invalid base URL scheme", "NIGHTLY_BASE_URL", "ftp://nightly.changelog.com/"},
+		{"Invalid source path template", "NIGHTLY_PATH_TEMPLATE", "{{.Year}/{{.Month}}"},
+		{"Invalid enrich flag", "NIGHTLY_ENRICH", "sometimes"},
+		{"Invalid lookup API", "NIGHTLY_LOOKUP_API", "soap"},
+		{"Invalid log level", "LOG_LEVEL", "verbose"},
+		{"Invalid log format", "LOG_FORMAT", "xml"},
+		{"Invalid metrics", "METRICS", "statsd"},
+		{"Prometheus without file", "METRICS", "prometheus"},
+		{"Invalid traces exporter", "OTEL_TRACES_EXPORTER", "jaeger"},
+		{"Invalid OTLP endpoint", "OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4318"},
+		{"Missing filter file", "NIGHTLY_FILTER_FILE", "/nonexistent/filter.json"},
+		{"Invalid time zone", "NIGHTLY_TIME_ZONE", "Mars/Olympus_Mons"},
+		{"Invalid cutoff hour", "NIGHTLY_CUTOFF_HOUR", "24"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(testEnv(map[string]string{tt.key: tt.value}))
+			if err == nil {
+				t.Errorf("LoadConfig() should have returned an error for %s=%q", tt.key, tt.value)
+			}
+		})
+	}
+}
+
+func TestUploadConfig_message(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := LoadConfig(testEnv(map[string]string{
+		"GITHUB_COMMIT_MESSAGE": "Trending {{.Date}}: {{.Total}} repos ({{.FirstTimers}}/{{.TopNew}}/{{.RepeatPerformers}})",
+		"GITHUB_CO_AUTHORS":     "Jane Doe <jane@example.com>;Joe <joe@example.com>",
+	}))
+	if err != nil {
+		t.Fatalf("LoadConfig() failed with error: %v", err)
+	}
+
+	trending := &nightly.TrendingRepos{
+		First:     []nightly.Repository{{}, {}},
+		New:       []nightly.Repository{{}},
+		Repeaters: []nightly.Repository{{}},
+	}
+	got, err := cfg.message(newCommitData(trending, time.Date(2018, 2, 8, 0, 0, 0, 0, time.UTC)))
+	if err != nil {
+		t.Fatalf("cfg.message() failed with error: %v", err)
+	}
+
+	want := strings.Join([]string{
+		"Trending 2018-02-08: 4 repos (2/1/1)",
+		"",
+		"Co-authored-by: Jane Doe <jane@example.com>",
+		"Co-authored-by: Joe <joe@example.com>",
+	}, "\n")
+	if got != want {
+		t.Errorf("cfg.message() = %q, want %q", got, want)
+	}
+}
+
+func TestPipeline_UploadConfig(t *testing.T) {
+	t.Parallel()
+
+	for _, api := range []string{"git", "contents"} {
+		api := api
+		t.Run(api, func(t *testing.T) {
+			t.Parallel()
+
+			stub := newStubGithub(t)
+			stub.CreateBranch("data")
+			p := newTestPipeline(t, stub, map[string]string{
+				"GITHUB_UPLOAD_API":      api,
+				"GITHUB_BRANCH":          "data",
+				"GITHUB_COMMITTER_NAME":  "Trending Bot",
+				"GITHUB_COMMITTER_EMAIL": "trending@example.com",
+				"GITHUB_AUTHOR_NAME":     "Jane Doe",
+				"GITHUB_AUTHOR_EMAIL":    "jane@example.com",
+				"GITHUB_PATH_TEMPLATE":   "{{.Year}}/{{.Month}}/{{.Date}}.json",
+			})
+
+			err := p.Run()
+			if err != nil {
+				t.Fatalf("failed executing Pipeline in test. error: %v", err)
+			}
+
+			path := "2018/02/2018-02-08.json"
+			if _, ok := stub.File("data", path); !ok {
+				t.Errorf("%s was not committed to the data branch", path)
+			}
+			if _, ok := stub.File("master", path); ok {
+				t.Errorf("%s should not be committed to the default branch", path)
+			}
+
+			head := stub.Head("data")
+			if head.Committer["name"] != "Trending Bot" || head.Committer["email"] != "trending@example.com" {
+				t.Errorf("Commit has committer %v, want Trending Bot", head.Committer)
+			}
+			if head.Author["name"] != "Jane Doe" || head.Author["email"] != "jane@example.com" {
+				t.Errorf("Commit has author %v, want Jane Doe", head.Author)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrPageNotFound is returned if the page of the day has not been published yet.
+var ErrPageNotFound = fmt.Errorf("Changelog Nightly page not found")
+
+// DayPolicy decides which day of Changelog Nightly is processed at a given time.
+// The page of a day is expected to be published after CutoffHour of the next day,
+// in the time zone of Location.
+type DayPolicy struct {
+	Location   *time.Location
+	CutoffHour int
+}
+
+// Day returns the most recent day whose page should already be published at now,
+// as midnight in the time zone of the policy. Before the cutoff hour that is the
+// day before yesterday, otherwise it is yesterday.
+func (d DayPolicy) Day(now time.Time) time.Time {
+	loc := d.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	local := now.In(loc)
+	days := 1
+	if local.Hour() < d.CutoffHour {
+		days = 2
+	}
+
+	// Calendar arithmetic on the date alone, so that DST changes can't skip or repeat a day
+	return time.Date(local.Year(), local.Month(), local.Day()-days, 0, 0, 0, 0, loc)
+}
+
+// previousDay() returns midnight of the day before the given day, in the same time zone.
+func previousDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day()-1, 0, 0, 0, 0, t.Location())
+}
+
+// downloadLatest() downloads the page of the given day or, if it has not been
+// published yet, the page of the day before. Returns the page and the day it is for.
+func (p *Pipeline) downloadLatest(ctx context.Context, day time.Time) (io.ReadCloser, time.Time, error) {
+	page, err := p.download(ctx, day)
+	if err != ErrPageNotFound {
+		return page, day, err
+	}
+
+	fallback := previousDay(day)
+	p.recorder().Add("download_retries", 1)
+	p.log(ctx, "download").Info("Page is not published yet, falling back to the day before", "day", day.Format("2006-01-02"), "fallback", fallback.Format("2006-01-02"))
+	page, err = p.download(ctx, fallback)
+	return page, fallback, err
+}
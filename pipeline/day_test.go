@@ -0,0 +1,64 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDayPolicy_Day(t *testing.T) {
+	t.Parallel()
+
+	chicago, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		policy DayPolicy
+		now    time.Time
+		want   string
+	}{
+		{"Default", DayPolicy{}, testNow, "2018-02-08"},
+		{"Before cutoff", DayPolicy{CutoffHour: 8}, testNow, "2018-02-07"},
+		{"After cutoff", DayPolicy{CutoffHour: 6}, testNow, "2018-02-08"},
+		// 05:00 UTC is still the previous evening in Chicago
+		{"Time zone", DayPolicy{Location: chicago}, time.Date(2018, 2, 9, 5, 0, 0, 0, time.UTC), "2018-02-07"},
+		// Midnight after the spring forward is only 23 hours after the previous midnight
+		{"DST start", DayPolicy{Location: chicago}, time.Date(2018, 3, 12, 0, 30, 0, 0, chicago), "2018-03-11"},
+		{"DST end", DayPolicy{Location: chicago}, time.Date(2018, 11, 5, 23, 30, 0, 0, chicago), "2018-11-04"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.policy.Day(tt.now)
+			if got.Format("2006-01-02") != tt.want {
+				t.Errorf("DayPolicy.Day() = %s, want %s", got.Format("2006-01-02"), tt.want)
+			}
+			if got.Hour() != 0 || got.Minute() != 0 {
+				t.Errorf("DayPolicy.Day() = %s, want midnight", got)
+			}
+		})
+	}
+}
+
+func TestPipeline_FallbackToPreviousDay(t *testing.T) {
+	t.Parallel()
+
+	stub := newStubGithub(t)
+	p := newTestPipeline(t, stub, nil)
+	downloader := p.Downloader.(*StubDownloader)
+	downloader.notFound = map[string]bool{"https://nightly.changelog.com/2018/02/08": true}
+
+	err := p.Run()
+	if err != nil {
+		t.Fatalf("failed executing Pipeline in test. error: %v", err)
+	}
+
+	want := []string{"https://nightly.changelog.com/2018/02/08", "https://nightly.changelog.com/2018/02/07"}
+	if len(downloader.gets) != len(want) || downloader.gets[0] != want[0] || downloader.gets[1] != want[1] {
+		t.Errorf("Downloaded %v, want %v", downloader.gets, want)
+	}
+	if _, ok := stub.File("master", "2018-02-07.json"); !ok {
+		t.Errorf("2018-02-07.json was not committed")
+	}
+}
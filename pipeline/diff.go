@@ -0,0 +1,72 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/github"
+	"github.com/quasoft/changelog-nightly-parser/history"
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+// previousDaily() returns the most recent day before the given day that is
+// listed in the index read from the branch of the Github repository (or the
+// configured branch, if branch is empty), or nil if there is none.
+func (p *Pipeline) previousDaily(ctx context.Context, idx *Index, branch string, day time.Time) (*history.Day, error) {
+	// The index is sorted from the most recent day
+	date := day.Format("2006-01-02")
+	for _, entry := range idx.Days {
+		if entry.Date >= date {
+			continue
+		}
+
+		content, _, err := p.githubAt(ctx, branch).GetFile(entry.Path)
+		if err != nil {
+			return nil, err
+		}
+		if content == nil {
+			return nil, fmt.Errorf("Daily file %s listed in %s does not exist", entry.Path, indexFileName)
+		}
+		prev, err := time.Parse("2006-01-02", entry.Date)
+		if err != nil {
+			return nil, err
+		}
+		trending := &nightly.TrendingRepos{}
+		err = json.Unmarshal(content, trending)
+		if err != nil {
+			return nil, err
+		}
+		return &history.Day{Date: prev, Trending: trending}, nil
+	}
+	return nil, nil
+}
+
+// diffPath() returns the path of the diff file of the daily file at path.
+func diffPath(path string) string {
+	return strings.TrimSuffix(path, ".json") + ".diff.json"
+}
+
+// diff() compares the trending repositories of the day with the previous day
+// in the index read from the branch of the Github repository, and returns the
+// comparison as a JSON file stored next to the daily file at path, and as
+// Markdown. Returns a nil file if no previous day has been published.
+func (p *Pipeline) diff(ctx context.Context, idx *Index, branch string, trending *nightly.TrendingRepos, day time.Time, path string) (*github.File, string, error) {
+	prev, err := p.previousDaily(ctx, idx, branch, day)
+	if err != nil {
+		return nil, "", err
+	}
+	if prev == nil {
+		p.log(ctx, "diff").Info("No previous day published, nothing to compare with")
+		return nil, "", nil
+	}
+
+	d := history.Diff(*prev, history.Day{Date: day, Trending: trending})
+	j, err := json.Marshal(d)
+	if err != nil {
+		return nil, "", err
+	}
+	return &github.File{Path: diffPath(path), Content: j}, d.Markdown(), nil
+}
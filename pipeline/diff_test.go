@@ -0,0 +1,65 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/history"
+)
+
+func TestPipeline_Diff(t *testing.T) {
+	t.Parallel()
+
+	stub := newStubGithub(t)
+	vars := map[string]string{"NIGHTLY_DIFF": "true"}
+
+	// The first day has nothing to compare with
+	p := newTestPipeline(t, stub, vars)
+	p.Now = func() time.Time { return testNow.AddDate(0, 0, -1) }
+	err := p.Run()
+	if err != nil {
+		t.Fatalf("Run() failed with error: %v", err)
+	}
+	if _, ok := stub.File("master", "2018-02-07.diff.json"); ok {
+		t.Errorf("Diff file should not be created without a previous day")
+	}
+
+	p = newTestPipeline(t, stub, vars)
+	requests := len(stub.Requests)
+	err = p.Run()
+	if err != nil {
+		t.Fatalf("Run() failed with error: %v", err)
+	}
+
+	// The index is read once, for both the index and the diff
+	indexReads := 0
+	for _, req := range stub.Requests[requests:] {
+		if strings.HasPrefix(req, "GET ") && strings.HasSuffix(req, "/contents/"+indexFileName) {
+			indexReads++
+		}
+	}
+	if indexReads != 1 {
+		t.Errorf("Run() read %s %d times, want once", indexFileName, indexReads)
+	}
+
+	content, ok := stub.File("master", "2018-02-08.diff.json")
+	if !ok {
+		t.Fatalf("Diff file was not uploaded")
+	}
+	d := history.DayDiff{}
+	err = json.Unmarshal(content, &d)
+	if err != nil {
+		t.Fatalf("Diff file is not valid JSON: %v", err)
+	}
+	if d.Date != "2018-02-08" || d.PreviousDate != "2018-02-07" || len(d.Categories) != 3 || len(d.Categories[0].Kept) == 0 {
+		t.Errorf("Diff file = %+v", d)
+	}
+
+	// The default commit message includes the diff
+	message := stub.Head("master").Message
+	if !strings.HasPrefix(message, "Uploading trending repos for 2018-02-08\n\n") || !strings.Contains(message, "### Changes since 2018-02-07") {
+		t.Errorf("Commit message does not include the diff: %s", message)
+	}
+}
@@ -0,0 +1,103 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/github"
+	"github.com/quasoft/changelog-nightly-parser/metrics"
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+// metadataClient() returns a client for reading the metadata of public repositories.
+// Requests go through the Downloader, so that they are cached and recorded like
+// readme lookups.
+func (p *Pipeline) metadataClient(ctx context.Context) *github.Client {
+	return &github.Client{
+		HTTP:    p.Downloader,
+		API:     p.GithubAPI,
+		Tokens:  p.tokens(),
+		Logger:  p.log(ctx, "enrich"),
+		Context: ctx,
+	}
+}
+
+// fetchMetadata() returns the metadata of the repository from the Github API.
+func (p *Pipeline) fetchMetadata(client *github.Client, name string) (*nightly.Metadata, error) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("Invalid repository name %q, expected owner/name", name)
+	}
+
+	repo, err := client.GetRepository(parts[0], parts[1])
+	if err != nil {
+		return nil, err
+	}
+	return metadataOf(repo), nil
+}
+
+// metadataOf() converts the Github metadata of a repository to nightly.Metadata.
+func metadataOf(repo *github.Repository) *nightly.Metadata {
+	meta := &nightly.Metadata{
+		Topics:     repo.Topics,
+		Forks:      repo.Forks,
+		OpenIssues: repo.OpenIssues,
+		CreatedAt:  repo.CreatedAt,
+		PushedAt:   repo.PushedAt,
+		Homepage:   repo.Homepage,
+		Archived:   repo.Archived,
+	}
+	if repo.License != nil {
+		meta.License = repo.License.SPDXID
+	}
+	return meta
+}
+
+// enrich() populates the metadata of the Github repositories in groups.
+// Repositories found by lookup() are taken from found, the others are requested
+// from the REST API, each only once, even if it is listed several times.
+// Repositories whose metadata can't be fetched are left without metadata.
+func (p *Pipeline) enrich(ctx context.Context, groups []*repoGroup, found map[string]*github.Repository) {
+	names := githubNames(groups)
+	p.log(ctx, "enrich").Info("Enriching repositories with Github metadata", "repos", len(names))
+	defer metrics.Since(p.recorder(), "enrich", time.Now())
+
+	client := p.metadataClient(ctx)
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, 10)
+
+	for _, g := range groups {
+		g := g
+		if g.name == "" {
+			continue
+		}
+		if repo, ok := found[g.name]; ok {
+			meta := metadataOf(repo)
+			for _, r := range g.repos {
+				r.Metadata = meta
+			}
+			continue
+		}
+
+		limit <- struct{}{}
+		wg.Add(1)
+		go func() {
+			meta, err := p.fetchMetadata(client, g.name)
+			if err != nil {
+				p.log(ctx, "enrich").Warn("Could not get metadata", "repo", g.name, "error", err)
+				p.recorder().Add("metadata_errors", 1)
+			} else {
+				for _, r := range g.repos {
+					r.Metadata = meta
+				}
+			}
+			<-limit
+			wg.Done()
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,69 @@
+package pipeline
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+func TestPipeline_enrich(t *testing.T) {
+	t.Parallel()
+
+	stub := newStubGithub(t)
+	stub.AddRepository("acme/rocket", map[string]interface{}{
+		"full_name":         "acme/rocket",
+		"topics":            []string{"go"},
+		"license":           map[string]string{"spdx_id": "MIT"},
+		"forks_count":       12,
+		"open_issues_count": 3,
+		"created_at":        "2018-01-02T03:04:05Z",
+		"pushed_at":         "2018-02-08T10:00:00Z",
+		"homepage":          "https://rocket.example.com",
+	})
+	p := newTestPipeline(t, stub, nil)
+	p.Downloader = stub.Client()
+
+	tr := &nightly.TrendingRepos{
+		First:     []nightly.Repository{{Name: "acme/rocket"}, {Name: "acme/missing"}},
+		Repeaters: []nightly.Repository{{Name: "Acme/Rocket"}},
+	}
+	p.enrich(context.Background(), groupRepos(tr), nil)
+
+	for _, r := range []nightly.Repository{tr.First[0], tr.Repeaters[0]} {
+		if r.Metadata == nil {
+			t.Fatalf("%s was not enriched", r.Name)
+		}
+		m := r.Metadata
+		if m.License != "MIT" || m.Forks != 12 || m.OpenIssues != 3 || m.Homepage != "https://rocket.example.com" || len(m.Topics) != 1 {
+			t.Errorf("%s has metadata %+v", r.Name, m)
+		}
+	}
+	if tr.First[1].Metadata != nil {
+		t.Errorf("Missing repository should have no metadata, got %+v", tr.First[1].Metadata)
+	}
+
+	requests := 0
+	for _, r := range stub.Requests {
+		if r == "GET /repos/acme/rocket" {
+			requests++
+		}
+	}
+	if requests != 1 {
+		t.Errorf("acme/rocket was requested %d times, want once", requests)
+	}
+}
+
+func TestPipeline_Daily_NoMetadata(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPipeline(t, nil, nil)
+	j, err := p.Daily(testNow.AddDate(0, 0, -1))
+	if err != nil {
+		t.Fatalf("Daily() failed with error: %v", err)
+	}
+	if strings.Contains(string(j), "Metadata") {
+		t.Errorf("Daily file should not contain metadata unless enrichment is enabled: %s", j)
+	}
+}
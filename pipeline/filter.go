@@ -0,0 +1,23 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/quasoft/changelog-nightly-parser/filter"
+	"github.com/quasoft/changelog-nightly-parser/github"
+)
+
+// filteredPath() returns the path of the filter report of the daily file at path.
+func filteredPath(path string) string {
+	return strings.TrimSuffix(path, ".json") + ".filtered.json"
+}
+
+// appendReport() adds the filter report as a JSON file next to the daily file at path.
+func appendReport(files []github.File, report *filter.Report, path string) ([]github.File, error) {
+	j, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+	return append(files, github.File{Path: filteredPath(path), Content: j}), nil
+}
@@ -0,0 +1,58 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/quasoft/changelog-nightly-parser/filter"
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+func TestPipeline_Filter(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "filter.json")
+	err := ioutil.WriteFile(file, []byte(`{"Languages": ["C"]}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stub := newStubGithub(t)
+	p := newTestPipeline(t, stub, map[string]string{"NIGHTLY_FILTER_FILE": file})
+	err = p.Run()
+	if err != nil {
+		t.Fatalf("Run() failed with error: %v", err)
+	}
+
+	daily, _ := stub.File("master", "2018-02-08.json")
+	trending := nightly.TrendingRepos{}
+	err = json.Unmarshal(daily, &trending)
+	if err != nil {
+		t.Fatalf("Daily file is not valid JSON: %v", err)
+	}
+	if trending.Count() == 0 {
+		t.Fatalf("All repositories were filtered out")
+	}
+	for _, repos := range [][]nightly.Repository{trending.First, trending.New, trending.Repeaters} {
+		for _, r := range repos {
+			if r.Language != "C" {
+				t.Errorf("%s (%s) should have been filtered out", r.Name, r.Language)
+			}
+		}
+	}
+
+	content, ok := stub.File("master", "2018-02-08.filtered.json")
+	if !ok {
+		t.Fatalf("Filter report was not uploaded")
+	}
+	report := filter.Report{}
+	err = json.Unmarshal(content, &report)
+	if err != nil {
+		t.Fatalf("Filter report is not valid JSON: %v", err)
+	}
+	if report.Kept != trending.Count() || len(report.Filtered) == 0 || len(report.Filtered[0].Reasons) == 0 {
+		t.Errorf("Filter report = %+v", report)
+	}
+}
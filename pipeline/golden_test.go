@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/archive"
+	"github.com/quasoft/changelog-nightly-parser/internal/golden"
+)
+
+// readmeCounter is an http.RoundTripper counting the readmes requested with the
+// REST API.
+type readmeCounter struct {
+	next  http.RoundTripper
+	count int32
+}
+
+func (c *readmeCounter) RoundTrip(r *http.Request) (*http.Response, error) {
+	if strings.HasSuffix(r.URL.Path, "/readme") {
+		atomic.AddInt32(&c.count, 1)
+	}
+	return c.next.RoundTrip(r)
+}
+
+// TestPipeline_Daily_Golden replays every nightly page archived in testdata/archive,
+// together with the readmes of its repositories, and compares the daily file with
+// testdata/golden/YYYY-MM-DD.json. Each page is replayed twice, looking up the
+// readmes with the REST API and with the recorded GraphQL queries, which must
+// produce the same daily file. Pages recorded with NIGHTLY_RECORD_DIR can be
+// copied to testdata/archive as-is. Run with -update to regenerate the golden files.
+func TestPipeline_Daily_Golden(t *testing.T) {
+	t.Parallel()
+
+	pages, err := filepath.Glob("testdata/archive/nightly.changelog.com/*/*/*.html")
+	if err != nil || len(pages) == 0 {
+		t.Fatalf("No archived pages found, error: %v", err)
+	}
+
+	for _, api := range []string{"rest", "graphql"} {
+		p := newTestPipeline(t, nil, map[string]string{"NIGHTLY_LOOKUP_API": api})
+		readmes := &readmeCounter{next: archive.NewReplayer("testdata/archive")}
+		p.Downloader = &http.Client{Transport: readmes}
+		p.Logger = NewLogger(ioutil.Discard, "text", slog.LevelInfo)
+
+		for _, page := range pages {
+			rel, _ := filepath.Rel("testdata/archive/nightly.changelog.com", page)
+			day, err := time.Parse("2006/01/02.html", filepath.ToSlash(rel))
+			if err != nil {
+				t.Fatalf("Unexpected archived page %s: %v", page, err)
+			}
+
+			date := day.Format("2006-01-02")
+			t.Run(date+"/"+api, func(t *testing.T) {
+				j, err := p.Daily(day)
+				if err != nil {
+					t.Fatalf("Daily() failed with error: %v", err)
+				}
+				golden.Assert(t, filepath.Join("testdata", "golden", date+".json"), golden.IndentJSON(t, j))
+			})
+		}
+
+		if api == "graphql" && readmes.count != 0 {
+			t.Errorf("Requested %d readmes with the REST API, want all of them looked up with GraphQL", readmes.count)
+		}
+	}
+}
+
+// TestPipeline_Daily_GoldenFilesUsed makes sure that no golden file is left
+// behind after its archived page is removed.
+func TestPipeline_Daily_GoldenFilesUsed(t *testing.T) {
+	t.Parallel()
+
+	files, err := filepath.Glob("testdata/golden/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		date := strings.TrimSuffix(filepath.Base(f), ".json")
+		page := filepath.Join("testdata/archive/nightly.changelog.com", strings.Replace(date, "-", "/", -1)+".html")
+		if _, err := ioutil.ReadFile(page); err != nil {
+			t.Errorf("Golden file %s has no archived page %s", f, page)
+		}
+	}
+}
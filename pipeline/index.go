@@ -0,0 +1,107 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/github"
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+const (
+	indexFileName  = "index.json"
+	latestFileName = "latest.json"
+)
+
+// IndexEntry describes a single daily file uploaded to the Github repository.
+type IndexEntry struct {
+	Date             string `json:"Date"`
+	Path             string `json:"Path"`
+	FirstTimers      int    `json:"FirstTimers"`
+	TopNew           int    `json:"TopNew"`
+	RepeatPerformers int    `json:"RepeatPerformers"`
+	Total            int    `json:"Total"`
+}
+
+// Index is the structure of the index.json file, which lists all daily files
+// available in the Github repository, the most recent day first.
+type Index struct {
+	Latest string       `json:"Latest"`
+	Days   []IndexEntry `json:"Days"`
+}
+
+// newIndexEntry() returns an index entry for the trending repos of the given day,
+// stored at the given path.
+func newIndexEntry(trending *nightly.TrendingRepos, path string, t time.Time) IndexEntry {
+	return IndexEntry{
+		Date:             t.Format("2006-01-02"),
+		Path:             path,
+		FirstTimers:      len(trending.First),
+		TopNew:           len(trending.New),
+		RepeatPerformers: len(trending.Repeaters),
+		Total:            trending.Count(),
+	}
+}
+
+// add() adds the entry to the index, replacing any existing entry for the same date,
+// and keeps the days sorted from the most recent to the oldest.
+func (idx *Index) add(entry IndexEntry) {
+	replaced := false
+	for i := range idx.Days {
+		if idx.Days[i].Date == entry.Date {
+			idx.Days[i] = entry
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		idx.Days = append(idx.Days, entry)
+	}
+
+	sort.SliceStable(idx.Days, func(i, j int) bool {
+		return idx.Days[i].Date > idx.Days[j].Date
+	})
+	idx.Latest = idx.Days[0].Path
+}
+
+// loadIndex() reads index.json from the branch of the Github repository (or the
+// configured branch, if branch is empty). Returns an empty index if the file
+// does not exist yet.
+func (p *Pipeline) loadIndex(ctx context.Context, branch string) (*Index, error) {
+	content, _, err := p.githubAt(ctx, branch).GetFile(indexFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := &Index{}
+	if len(content) > 0 {
+		err = json.Unmarshal(content, idx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return idx, nil
+}
+
+// indexFiles() adds the daily file to the index loaded with loadIndex(), and
+// returns index.json and latest.json with the contents of the daily file, if it
+// is the most recent day in the index. The files should be committed together
+// with the daily file, so that the index never references a file that does not exist.
+func (p *Pipeline) indexFiles(ctx context.Context, idx *Index, trending *nightly.TrendingRepos, daily []byte, path string, t time.Time) ([]github.File, error) {
+	idx.add(newIndexEntry(trending, path, t))
+
+	j, err := json.Marshal(idx)
+	if err != nil {
+		return nil, err
+	}
+	files := []github.File{{Path: indexFileName, Content: j}}
+
+	if idx.Latest != path {
+		p.log(ctx, "index").Info("Not updating the latest alias, a more recent day is published", "alias", latestFileName, "latest", idx.Latest, "path", path)
+		return files, nil
+	}
+
+	return append(files, github.File{Path: latestFileName, Content: daily}), nil
+}
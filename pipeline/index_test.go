@@ -0,0 +1,131 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+func TestIndex_add(t *testing.T) {
+	idx := Index{}
+	idx.add(IndexEntry{Date: "2018-02-07", Path: "2018-02-07.json", Total: 1})
+	idx.add(IndexEntry{Date: "2018-02-08", Path: "2018-02-08.json", Total: 2})
+	idx.add(IndexEntry{Date: "2018-02-06", Path: "2018-02-06.json", Total: 3})
+	idx.add(IndexEntry{Date: "2018-02-07", Path: "2018-02-07.json", Total: 4})
+
+	gotLen := len(idx.Days)
+	wantLen := 3
+	if gotLen != wantLen {
+		t.Fatalf("Index has %d days, want %d", gotLen, wantLen)
+	}
+
+	wantDates := []string{"2018-02-08", "2018-02-07", "2018-02-06"}
+	for i, want := range wantDates {
+		if idx.Days[i].Date != want {
+			t.Errorf("idx.Days[%d].Date = %v, want %v", i, idx.Days[i].Date, want)
+		}
+	}
+
+	if idx.Days[1].Total != 4 {
+		t.Errorf("idx.Days[1].Total = %v, want %v", idx.Days[1].Total, 4)
+	}
+
+	wantLatest := "2018-02-08.json"
+	if idx.Latest != wantLatest {
+		t.Errorf("idx.Latest = %v, want %v", idx.Latest, wantLatest)
+	}
+}
+
+func TestPipeline_indexFiles(t *testing.T) {
+	existing, _ := json.Marshal(Index{
+		Latest: "2018-02-07.json",
+		Days:   []IndexEntry{{Date: "2018-02-07", Path: "2018-02-07.json"}},
+	})
+
+	trending := &nightly.TrendingRepos{
+		First: []nightly.Repository{{Name: "user1/repo1"}, {Name: "user2/repo2"}},
+		New:   []nightly.Repository{{Name: "user3/repo3"}},
+	}
+	daily := []byte(`{"FirstTimers":[]}`)
+
+	tests := []struct {
+		name       string
+		date       time.Time
+		wantLatest bool
+	}{
+		{"Most recent day", time.Date(2018, 2, 8, 0, 0, 0, 0, time.UTC), true},
+		{"Backfilled day", time.Date(2018, 2, 6, 0, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPipeline(t, nil, nil)
+			stub := NewStubUploader()
+			stub.files[indexFileName] = existing
+			p.Uploader = stub
+
+			path := tt.date.Format("2006-01-02.json")
+			loaded, err := p.loadIndex(context.Background(), "")
+			if err != nil {
+				t.Fatalf("loadIndex() failed with error: %v", err)
+			}
+			files, err := p.indexFiles(context.Background(), loaded, trending, daily, path, tt.date)
+			if err != nil {
+				t.Fatalf("indexFiles() failed with error: %v", err)
+			}
+
+			got := map[string][]byte{}
+			for _, f := range files {
+				got[f.Path] = f.Content
+			}
+
+			idx := Index{}
+			err = json.Unmarshal(got[indexFileName], &idx)
+			if err != nil {
+				t.Fatalf("Decoding index failed with error: %v", err)
+			}
+			if len(idx.Days) != 2 {
+				t.Fatalf("Index has %d days, want %d", len(idx.Days), 2)
+			}
+
+			var entry IndexEntry
+			for _, e := range idx.Days {
+				if e.Path == path {
+					entry = e
+				}
+			}
+			if entry.FirstTimers != 2 || entry.TopNew != 1 || entry.RepeatPerformers != 0 || entry.Total != 3 {
+				t.Errorf("Index entry for %s has wrong counts: %+v", path, entry)
+			}
+
+			latest, gotLatest := got[latestFileName]
+			if gotLatest != tt.wantLatest {
+				t.Errorf("%s included = %v, want %v", latestFileName, gotLatest, tt.wantLatest)
+			}
+			if gotLatest && string(latest) != string(daily) {
+				t.Errorf("%s does not have the content of the daily file", latestFileName)
+			}
+		})
+	}
+}
+
+func TestPipeline_History(t *testing.T) {
+	t.Parallel()
+
+	stub := newStubGithub(t)
+	p := newTestPipeline(t, stub, nil)
+	err := p.Run()
+	if err != nil {
+		t.Fatalf("Run() failed with error: %v", err)
+	}
+
+	days, err := p.History()
+	if err != nil {
+		t.Fatalf("History() failed with error: %v", err)
+	}
+	if len(days) != 1 || days[0].Date.Format("2006-01-02") != "2018-02-08" || days[0].Trending.Count() == 0 {
+		t.Errorf("History() = %+v, want the uploaded day", days)
+	}
+}
@@ -0,0 +1,84 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+// NewLogger returns a structured logger writing records of at least the level
+// to w, as JSON lines if format is "json" or as key=value text otherwise.
+func NewLogger(w io.Writer, format string, level slog.Level) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == "json" {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// newRunID() returns a random ID for correlating the log records of a run.
+func newRunID() string {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		// The ID is only informative, a run must not fail because of it
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
+
+// loggerKey is the context key of the logger of a run.
+type loggerKey struct{}
+
+// withLogger() returns a copy of ctx carrying the logger of a run, so that
+// concurrent runs of the same pipeline do not share their run IDs and dates.
+func withLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// logger() returns the logger of the run in ctx, or the logger of the pipeline
+// outside of a run.
+func (p *Pipeline) logger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return p.Logger
+}
+
+// log() returns the logger of the run in ctx for records of a phase of the run
+// (eg. "download" or "screenshots").
+func (p *Pipeline) log(ctx context.Context, phase string) *slog.Logger {
+	return p.logger(ctx).With("phase", phase)
+}
+
+// logSummary() logs the outcome of a run in a single record.
+func (p *Pipeline) logSummary(ctx context.Context, trending *nightly.TrendingRepos, link string, started time.Time, err error) {
+	screenshots, total := 0, 0
+	if trending != nil {
+		total = trending.Count()
+		for _, repos := range [][]nightly.Repository{trending.First, trending.New, trending.Repeaters} {
+			for _, r := range repos {
+				if r.Screenshot != "" {
+					screenshots++
+				}
+			}
+		}
+	}
+
+	attrs := []any{
+		"phase", "summary",
+		"repos", total,
+		"screenshots", screenshots,
+		"duration", time.Since(started).Round(time.Millisecond).String(),
+	}
+	if err != nil {
+		p.logger(ctx).Error("Run failed", append(attrs, "error", err)...)
+		return
+	}
+	p.logger(ctx).Info("Run finished", append(attrs, "url", link)...)
+}
@@ -0,0 +1,133 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// logRecords() decodes the JSON lines logged to buf.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	records := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		record := map[string]interface{}{}
+		err := json.Unmarshal([]byte(line), &record)
+		if err != nil {
+			t.Fatalf("Log line %q is not JSON: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestPipeline_Run_Logs(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	p := newTestPipeline(t, newStubGithub(t), nil)
+	p.Logger = NewLogger(&buf, "json", slog.LevelDebug)
+	err := p.Run()
+	if err != nil {
+		t.Fatalf("Run() failed with error: %v", err)
+	}
+
+	records := logRecords(t, &buf)
+	runID := records[0]["run_id"]
+	if runID == nil || runID == "" {
+		t.Fatalf("Record %v has no run ID", records[0])
+	}
+	chosen := 0
+	for _, r := range records {
+		if r["run_id"] != runID {
+			t.Errorf("Record %v has a different run ID than %v", r, runID)
+		}
+		if r["phase"] == nil {
+			t.Errorf("Record %v has no phase", r)
+		}
+		if r["msg"] == "Screenshot chosen" {
+			chosen++
+			if r["date"] != "2018-02-08" || r["repo"] == nil || r["level"] != "DEBUG" {
+				t.Errorf("Record %v should have the date, the repo and the debug level", r)
+			}
+		}
+	}
+	if chosen == 0 {
+		t.Errorf("No screenshot records were logged at the debug level")
+	}
+
+	summary := records[len(records)-1]
+	if summary["msg"] != "Run finished" || summary["phase"] != "summary" || summary["date"] != "2018-02-08" ||
+		summary["repos"] == float64(0) || summary["screenshots"] == nil || summary["url"] == nil {
+		t.Errorf("Last record = %v, want the summary of the run", summary)
+	}
+
+	// The run ID of the next run is different, and debug records can be disabled
+	buf.Reset()
+	p.Logger = NewLogger(&buf, "json", slog.LevelInfo)
+	err = p.Run()
+	if err != nil {
+		t.Fatalf("Run() failed with error: %v", err)
+	}
+	for _, r := range logRecords(t, &buf) {
+		if r["run_id"] == runID || r["level"] == "DEBUG" {
+			t.Errorf("Record %v is from the previous run or below the info level", r)
+		}
+	}
+}
+
+func TestPipeline_Run_LogsConcurrent(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	p := newTestPipeline(t, newStubGithub(t), nil)
+	logger := NewLogger(&buf, "json", slog.LevelInfo)
+	p.Logger = logger
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.Run()
+		}()
+	}
+	wg.Wait()
+
+	if p.Logger != logger {
+		t.Errorf("Run() should not replace the logger of the pipeline")
+	}
+	summaries := map[interface{}]bool{}
+	for _, r := range logRecords(t, &buf) {
+		if r["phase"] == "summary" {
+			summaries[r["run_id"]] = true
+		}
+	}
+	if len(summaries) != 2 {
+		t.Errorf("Logged summaries of %d run IDs, want 2", len(summaries))
+	}
+}
+
+func TestPipeline_Run_LogsFailure(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	p := newTestPipeline(t, newStubGithub(t), nil)
+	p.Downloader.(*StubDownloader).errorToReturn = fmt.Errorf("unexpected error")
+	p.Logger = NewLogger(&buf, "text", slog.LevelInfo)
+	err := p.Run()
+	if err == nil {
+		t.Fatalf("Run() should have failed")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	last := lines[len(lines)-1]
+	for _, want := range []string{"level=ERROR", `msg="Run failed"`, "phase=summary", "repos=0", "date=2018-02-08", "unexpected error"} {
+		if !strings.Contains(last, want) {
+			t.Errorf("Last record %q does not contain %q", last, want)
+		}
+	}
+}
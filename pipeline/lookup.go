@@ -0,0 +1,87 @@
+package pipeline
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/github"
+	"github.com/quasoft/changelog-nightly-parser/metrics"
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+// repoGroup is a repository listed once or more on the nightly page, possibly
+// with different variants of its URL.
+type repoGroup struct {
+	// name is the lowercase "owner/name" of a Github repository, or empty if the
+	// repository is hosted elsewhere.
+	name  string
+	repos []*nightly.Repository
+}
+
+// groupRepos() groups the repositories in all three categories inside
+// TrendingRepos by their canonical URL, in the order of the nightly page.
+// Repositories without a URL are assumed to be on Github, as the nightly pages
+// only list Github repositories.
+func groupRepos(tr *nightly.TrendingRepos) []*repoGroup {
+	groups := []*repoGroup{}
+	byURL := map[string]*repoGroup{}
+	all := [][]nightly.Repository{tr.First, tr.New, tr.Repeaters}
+	for cat := range all {
+		for i := range all[cat] {
+			r := &all[cat][i]
+			key := strings.ToLower(r.Name)
+			if r.URL != "" {
+				key = r.CanonicalURL()
+			}
+
+			g, ok := byURL[key]
+			if !ok {
+				g = &repoGroup{}
+				if r.URL == "" {
+					g.name = strings.ToLower(r.Name)
+				} else if ref, err := r.Ref(); err == nil {
+					if _, ok := ref.Host.(nightly.GitHub); ok {
+						g.name = strings.ToLower(ref.FullName())
+					}
+				}
+				byURL[key] = g
+				groups = append(groups, g)
+			}
+			g.repos = append(g.repos, r)
+		}
+	}
+	return groups
+}
+
+// githubNames() returns the names of the groups of Github repositories.
+func githubNames(groups []*repoGroup) []string {
+	names := []string{}
+	for _, g := range groups {
+		if g.name != "" {
+			names = append(names, g.name)
+		}
+	}
+	return names
+}
+
+// lookup() fetches the metadata, default branch and readme of the repositories
+// with batched GraphQL queries. The result is keyed by the given names.
+// Returns nil if GraphQL is disabled or not available, so that the REST API
+// is used for each repository instead. GraphQL is not used without a token,
+// which it requires.
+func (p *Pipeline) lookup(ctx context.Context, names []string) map[string]*github.Repository {
+	if p.Config.LookupAPI == "rest" || p.tokens() == nil || len(names) == 0 {
+		return nil
+	}
+
+	defer metrics.Since(p.recorder(), "lookup", time.Now())
+	repos, err := p.metadataClient(ctx).GetRepositories(names)
+	if err != nil {
+		p.log(ctx, "lookup").Warn("GraphQL lookup failed, falling back to REST", "error", err)
+		p.recorder().Add("lookup_retries", 1)
+		return nil
+	}
+	p.log(ctx, "lookup").Info("Looked up repositories with GraphQL", "found", len(repos), "repos", len(names))
+	return repos
+}
@@ -0,0 +1,85 @@
+package pipeline
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+func TestPipeline_lookup(t *testing.T) {
+	t.Parallel()
+
+	stub := newStubGithub(t)
+	stub.AddRepository("acme/rocket", map[string]interface{}{
+		"full_name":      "acme/rocket",
+		"license":        map[string]string{"spdx_id": "MIT"},
+		"forks_count":    12,
+		"default_branch": "main",
+	})
+	stub.AddReadme("acme/rocket", "README.md", "# Rocket\n[![Build](https://travis-ci.org/acme/rocket.svg)](https://travis-ci.org)\n![Screenshot](docs/screenshot.png)")
+	p := newTestPipeline(t, stub, nil)
+	p.Downloader = stub.Client()
+
+	tr := &nightly.TrendingRepos{
+		First:     []nightly.Repository{{Name: "acme/rocket", URL: "https://github.com/acme/rocket"}},
+		Repeaters: []nightly.Repository{{Name: "Acme/Rocket", URL: "https://github.com/acme/rocket"}},
+	}
+	groups := groupRepos(tr)
+	found := p.lookup(context.Background(), githubNames(groups))
+	if len(found) != 1 {
+		t.Fatalf("lookup() found %d repositories, want 1", len(found))
+	}
+	p.populateScreenshots(context.Background(), groups, found)
+	p.enrich(context.Background(), groups, found)
+
+	for _, r := range []nightly.Repository{tr.First[0], tr.Repeaters[0]} {
+		if want := "https://raw.githubusercontent.com/acme/rocket/HEAD/docs/screenshot.png"; r.Screenshot != want {
+			t.Errorf("%s has screenshot %q, want %q", r.Name, r.Screenshot, want)
+		}
+		if r.Metadata == nil || r.Metadata.License != "MIT" || r.Metadata.Forks != 12 {
+			t.Errorf("%s has metadata %+v", r.Name, r.Metadata)
+		}
+	}
+
+	for _, r := range stub.Requests {
+		if strings.HasPrefix(r, "GET /repos/acme/") {
+			t.Errorf("Unexpected REST request %q, everything should come from GraphQL", r)
+		}
+	}
+}
+
+func TestPipeline_lookup_Fallback(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		vars       map[string]string
+		noToken    bool
+		failWrites int
+	}{
+		{"REST configured", map[string]string{"NIGHTLY_LOOKUP_API": "rest"}, false, 0},
+		{"No token", nil, true, 0},
+		{"GraphQL failure", nil, false, 502},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stub := newStubGithub(t)
+			stub.AddRepository("acme/rocket", map[string]interface{}{"full_name": "acme/rocket"})
+			stub.FailWrites = tt.failWrites
+			p := newTestPipeline(t, stub, tt.vars)
+			p.Downloader = stub.Client()
+			if tt.noToken {
+				p.Config.Token = ""
+			}
+
+			if found := p.lookup(context.Background(), []string{"acme/rocket"}); found != nil {
+				t.Errorf("lookup() = %v, want nil to fall back to REST", found)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/metrics"
+)
+
+// newRecorder() returns the metrics recorder selected in the configuration, or
+// a recorder discarding all metrics if none is selected.
+func newRecorder(cfg *Config) metrics.Recorder {
+	switch cfg.Metrics {
+	case "emf":
+		return metrics.NewEMF(os.Stdout, cfg.MetricsNamespace)
+	case "prometheus":
+		return metrics.NewPrometheus(cfg.MetricsFile)
+	default:
+		return metrics.Nop{}
+	}
+}
+
+// recorder() returns the metrics recorder of the pipeline, or a recorder
+// discarding all metrics if none is set (eg. in a Pipeline literal).
+func (p *Pipeline) recorder() metrics.Recorder {
+	if p.Metrics == nil {
+		return metrics.Nop{}
+	}
+	return p.Metrics
+}
+
+// flushMetrics() records the outcome and duration of a run started at started,
+// and flushes the metrics. Flush errors are only logged, so that they do not
+// fail the run.
+func (p *Pipeline) flushMetrics(ctx context.Context, started time.Time, runErr error) {
+	if runErr != nil {
+		p.recorder().Add("runs_failed", 1)
+	} else {
+		p.recorder().Add("runs_succeeded", 1)
+	}
+	metrics.Since(p.recorder(), "run", started)
+
+	err := p.recorder().Flush()
+	if err != nil {
+		p.log(ctx, "metrics").Warn("Could not flush metrics", "error", err)
+	}
+}
@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/quasoft/changelog-nightly-parser/metrics"
+)
+
+func TestPipeline_Run_Metrics(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "nightly.prom")
+	p := newTestPipeline(t, newStubGithub(t), map[string]string{"METRICS": "prometheus", "METRICS_FILE": file})
+	prom, ok := p.Metrics.(*metrics.Prometheus)
+	if !ok {
+		t.Fatalf("Metrics = %T, want a Prometheus recorder", p.Metrics)
+	}
+	err := p.Run()
+	if err != nil {
+		t.Fatalf("Run() failed with error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	prom.WriteTo(&buf)
+	got := buf.String()
+	for _, want := range []string{
+		"nightly_runs_succeeded_total 1\n",
+		"nightly_repos_parsed_total ",
+		"nightly_screenshots_found_total ",
+		"nightly_download_duration_seconds_count 1\n",
+		"nightly_parse_duration_seconds_count 1\n",
+		"nightly_screenshots_duration_seconds_count 1\n",
+		"nightly_upload_duration_seconds_count 1\n",
+		"nightly_run_duration_seconds_count 1\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Metrics do not contain %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "runs_failed") {
+		t.Errorf("Successful run was counted as failed:\n%s", got)
+	}
+}
+
+func TestNewPipeline_Metrics(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPipeline(t, nil, nil)
+	if _, ok := p.Metrics.(metrics.Nop); !ok {
+		t.Errorf("Metrics = %T, want metrics.Nop by default", p.Metrics)
+	}
+	p = newTestPipeline(t, nil, map[string]string{"METRICS": "emf", "METRICS_NAMESPACE": "Trending"})
+	if emf, ok := p.Metrics.(*metrics.EMF); !ok || emf.Namespace != "Trending" {
+		t.Errorf("Metrics = %#v, want an EMF recorder in the Trending namespace", p.Metrics)
+	}
+}
+
+func TestPipeline_Run_NilMetrics(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPipeline(t, newStubGithub(t), nil)
+	p.Metrics = nil
+	err := p.Run()
+	if err != nil {
+		t.Fatalf("Run() failed with error: %v", err)
+	}
+}
+
+func TestPipeline_collect_ParseFailureMetrics(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "nightly.prom")
+	p := newTestPipeline(t, nil, map[string]string{"METRICS": "prometheus", "METRICS_FILE": file})
+	_, _, err := p.collect(context.Background(), iotest.ErrReader(fmt.Errorf("connection reset")))
+	if err == nil {
+		t.Fatalf("collect() should have failed")
+	}
+
+	var buf bytes.Buffer
+	p.Metrics.(*metrics.Prometheus).WriteTo(&buf)
+	if !strings.Contains(buf.String(), "nightly_parse_duration_seconds_count 1\n") {
+		t.Errorf("Duration of the failed parse was not recorded:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,47 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+	"github.com/quasoft/changelog-nightly-parser/notify"
+)
+
+// notifyTimeout limits each request to a webhook, so that a slow webhook does
+// not hold up the end of the run.
+const notifyTimeout = 30 * time.Second
+
+// fileURL() returns the link to the file in the branch of the Github repository,
+// or in its default branch if branch is empty.
+func (cfg *Config) fileURL(branch string, path string) string {
+	if branch == "" {
+		branch = "HEAD"
+	}
+	return fmt.Sprintf("https://github.com/%s/%s/blob/%s/%s", cfg.Owner, cfg.Repository, branch, path)
+}
+
+// notify() sends the summary of a run to the notifier, if any. Trending may be nil
+// if the run failed before the page was collected. Notification errors are only
+// logged, so that they do not fail the run.
+func (p *Pipeline) notify(ctx context.Context, day time.Time, trending *nightly.TrendingRepos, link string, runErr error) {
+	if p.Notifier == nil {
+		return
+	}
+
+	if trending == nil {
+		trending = &nightly.TrendingRepos{}
+	}
+	s := notify.NewSummary(day.Format("2006-01-02"), trending)
+	s.FileURL = link
+	if runErr != nil {
+		s.Error = runErr.Error()
+	}
+
+	err := p.Notifier.Notify(ctx, s)
+	if err != nil {
+		p.log(ctx, "notify").Warn("Notification failed", "error", err)
+		p.recorder().Add("notifications_failed", 1)
+	}
+}
@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/quasoft/changelog-nightly-parser/notify"
+)
+
+// newWebhookServer() returns a stand-in for a webhook endpoint, replying with the
+// status, and a function returning the summaries posted to it so far.
+func newWebhookServer(t *testing.T, status int) (*httptest.Server, func() []notify.Summary) {
+	var mu sync.Mutex
+	summaries := []notify.Summary{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var s notify.Summary
+		json.NewDecoder(r.Body).Decode(&s)
+		summaries = append(summaries, s)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []notify.Summary {
+		mu.Lock()
+		defer mu.Unlock()
+		return summaries
+	}
+}
+
+func TestPipeline_notify(t *testing.T) {
+	t.Parallel()
+
+	server, posted := newWebhookServer(t, http.StatusOK)
+	p := newTestPipeline(t, newStubGithub(t), map[string]string{"NOTIFY_WEBHOOK_URL": server.URL})
+	err := p.Run()
+	if err != nil {
+		t.Fatalf("Run() failed with error: %v", err)
+	}
+
+	got := posted()
+	if len(got) != 1 {
+		t.Fatalf("Webhook was notified %d times, want once", len(got))
+	}
+	s := got[0]
+	wantURL := "https://github.com/user/trending-daily/blob/master/2018-02-08.json"
+	if s.Date != "2018-02-08" || s.Total == 0 || len(s.TopRepos) == 0 || s.FileURL != wantURL || s.Error != "" {
+		t.Errorf("Summary = %+v, want a successful run linking to %s", s, wantURL)
+	}
+}
+
+func TestPipeline_notify_Failure(t *testing.T) {
+	t.Parallel()
+
+	server, posted := newWebhookServer(t, http.StatusOK)
+	stub := newStubGithub(t)
+	stub.FailWrites = http.StatusBadRequest
+	p := newTestPipeline(t, stub, map[string]string{
+		"NOTIFY_WEBHOOK_URL":   server.URL,
+		"NOTIFY_FAILURES_ONLY": "true",
+	})
+	runErr := p.Run()
+	if runErr == nil {
+		t.Fatalf("Run() should have failed")
+	}
+
+	got := posted()
+	if len(got) != 1 || got[0].Error != runErr.Error() || got[0].FileURL != "" {
+		t.Errorf("Summaries = %+v, want one failure with error %q", got, runErr)
+	}
+}
+
+func TestPipeline_notify_WebhookFail(t *testing.T) {
+	t.Parallel()
+
+	server, posted := newWebhookServer(t, http.StatusInternalServerError)
+	p := newTestPipeline(t, newStubGithub(t), map[string]string{"NOTIFY_SLACK_URL": server.URL})
+	err := p.Run()
+	if err != nil {
+		t.Errorf("Run() should not fail when notifying fails, got error: %v", err)
+	}
+	if len(posted()) != 1 {
+		t.Errorf("Slack webhook was not notified")
+	}
+}
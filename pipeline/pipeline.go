@@ -0,0 +1,389 @@
+// Package pipeline downloads the Changelog Nightly page of the previous day,
+// detects a screenshot for each trending repository and publishes the result,
+// together with an index of all days, to a Github repository.
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/archive"
+	"github.com/quasoft/changelog-nightly-parser/filter"
+	"github.com/quasoft/changelog-nightly-parser/github"
+	"github.com/quasoft/changelog-nightly-parser/history"
+	"github.com/quasoft/changelog-nightly-parser/httpcache"
+	"github.com/quasoft/changelog-nightly-parser/metrics"
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+	"github.com/quasoft/changelog-nightly-parser/notify"
+	"github.com/quasoft/changelog-nightly-parser/trace"
+)
+
+// The Downloader interface represent a type that can send HTTP requests,
+// like http.Client.
+type Downloader interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// Pipeline holds everything needed for a single run: the HTTP clients, the upload
+// configuration, the logger and the clock. Pipelines do not share any state, so
+// multiple pipelines with different configurations can run in the same process.
+type Pipeline struct {
+	// Downloader is used for the Changelog Nightly page and for readme files.
+	Downloader Downloader
+	// Uploader is used for all requests to the Github API of the target repository.
+	Uploader github.Doer
+	// GithubAPI is the base URL of the Github API used for uploads.
+	GithubAPI string
+
+	Config *Config
+	// Logger receives structured records of every phase of a run.
+	Logger *slog.Logger
+	// Notifier is sent the summary of each run, if not nil.
+	Notifier notify.Notifier
+	// Metrics records counters and durations of every stage of a run, if not nil.
+	Metrics metrics.Recorder
+	// Tracer records spans of the stages of a run, if not nil.
+	Tracer *trace.Tracer
+	// Now returns the current time. Config.Day decides which day is processed.
+	Now func() time.Time
+
+	tokensOnce sync.Once
+	tokenSrc   github.TokenSource
+}
+
+// NewPipeline returns a pipeline using http.Client for all requests, logging to
+// stderr and using the system clock. Downloads are cached if cfg.CacheDir is set
+// and recorded if cfg.RecordDir is set. All requests are traced if cfg.TraceExporter
+// is set.
+func NewPipeline(cfg *Config) *Pipeline {
+	transport := http.DefaultTransport
+	if cfg.CacheDir != "" {
+		transport = httpcache.New(cfg.CacheDir, transport)
+	}
+	if cfg.RecordDir != "" {
+		transport = archive.NewRecorder(cfg.RecordDir, transport)
+	}
+	tracer := newTracer(cfg)
+	uploadTransport := http.DefaultTransport
+	if tracer != nil {
+		transport = trace.NewTransport(tracer, transport)
+		uploadTransport = trace.NewTransport(tracer, uploadTransport)
+	}
+	downloader := &http.Client{Transport: transport}
+
+	return &Pipeline{
+		Downloader: downloader,
+		Uploader:   &http.Client{Transport: uploadTransport},
+		GithubAPI:  github.DefaultAPI,
+		Config:     cfg,
+		Logger:     NewLogger(os.Stderr, cfg.LogFormat, cfg.LogLevel),
+		Notifier:   notify.New(cfg.Notify, &http.Client{Timeout: notifyTimeout}),
+		Metrics:    newRecorder(cfg),
+		Tracer:     tracer,
+		Now:        time.Now,
+	}
+}
+
+// tokens() returns the source of tokens for Github API requests, creating it on
+// first use from the configuration of the pipeline.
+func (p *Pipeline) tokens() github.TokenSource {
+	p.tokensOnce.Do(func() {
+		app := p.Config.App
+		if app == nil {
+			// Requests are sent unauthorized without a token, eg. when replaying
+			if p.Config.Token != "" {
+				p.tokenSrc = github.StaticToken(p.Config.Token)
+			}
+			return
+		}
+
+		p.tokenSrc = &github.AppTokenSource{
+			AppID:          app.ID,
+			InstallationID: app.InstallationID,
+			Key:            app.Key,
+			Client:         p.Uploader,
+			API:            p.GithubAPI,
+			Logger:         p.Logger.With("phase", "auth"),
+			Now:            p.Now,
+		}
+	})
+	return p.tokenSrc
+}
+
+// github() returns a client for the Github repository the pipeline publishes to,
+// sending all requests with the context.
+func (p *Pipeline) github(ctx context.Context) *github.Client {
+	return &github.Client{
+		HTTP:       p.Uploader,
+		API:        p.GithubAPI,
+		Tokens:     p.tokens(),
+		Logger:     p.log(ctx, "publish"),
+		Owner:      p.Config.Owner,
+		Repository: p.Config.Repository,
+		Branch:     p.Config.Branch,
+		Committer:  p.Config.Committer,
+		Author:     p.Config.Author,
+		Context:    ctx,
+	}
+}
+
+// githubAt() is like github(), but reads files from branch instead of the
+// configured branch, if branch is not empty.
+func (p *Pipeline) githubAt(ctx context.Context, branch string) *github.Client {
+	gh := p.github(ctx)
+	if branch != "" {
+		gh.Branch = branch
+	}
+	return gh
+}
+
+// History loads the daily files uploaded to the Github repository, as listed
+// in its index, sorted from the oldest day to the most recent one.
+func (p *Pipeline) History() ([]history.Day, error) {
+	return history.LoadIndexed(p.github(context.Background()))
+}
+
+// publish() commits the files to the Github repository. By default all files are
+// written in a single commit via the Git Data API. If the upload API is set to
+// "contents", the files are uploaded one by one via the Contents API instead,
+// creating one commit per file. In pull request mode the files are always committed
+// with the Git Data API, to a branch created from the branch from (or the configured
+// branch, if from is empty). Returns a link to the pull request, or to the first
+// file. The upload is traced in an "uploadToGithub" span.
+func (p *Pipeline) publish(ctx context.Context, files []github.File, message string, data CommitData, from string) (link string, err error) {
+	defer metrics.Since(p.recorder(), "upload", time.Now())
+	ctx, span := p.Tracer.Start(ctx, "uploadToGithub", "files", len(files))
+	defer func() {
+		span.SetAttributes("url", link)
+		span.SetError(err)
+		span.Finish()
+	}()
+
+	gh := p.github(ctx)
+
+	if p.Config.PullRequest {
+		head, err := p.Config.pullBranch(data)
+		if err != nil {
+			return "", err
+		}
+		pull, err := gh.OpenPullRequest(files, message, github.PullRequestOptions{
+			Head:        head,
+			From:        from,
+			Body:        pullRequestBody(data),
+			Labels:      p.Config.PullLabels,
+			AutoMerge:   p.Config.AutoMerge,
+			MergeMethod: p.Config.MergeMethod,
+		})
+		if err != nil {
+			return "", err
+		}
+		return pull.HTMLURL, nil
+	}
+
+	if p.Config.UploadAPI != "contents" {
+		branch, err := gh.TargetBranch()
+		if err != nil {
+			return "", err
+		}
+		err = gh.Commit(branch, files, message)
+		if err != nil {
+			return "", err
+		}
+		return p.Config.fileURL(branch, files[0].Path), nil
+	}
+
+	for _, f := range files {
+		err := gh.UploadFile(f.Content, f.Path, message)
+		if err != nil {
+			return "", err
+		}
+	}
+	return p.Config.fileURL(p.Config.Branch, files[0].Path), nil
+}
+
+// collect() parses the nightly page, filters the repositories found (if rules
+// are configured), merges duplicates (if enabled) and populates the screenshots
+// (and metadata, if enabled) of the repositories kept. The report of the filter
+// is nil if no rules are configured. Parsing is traced in a "parseNightlyPage" span.
+func (p *Pipeline) collect(ctx context.Context, page io.Reader) (*nightly.TrendingRepos, *filter.Report, error) {
+	parseStart := time.Now()
+	_, span := p.Tracer.Start(ctx, "parseNightlyPage")
+	trending, err := nightly.Parse(page)
+	metrics.Since(p.recorder(), "parse", parseStart)
+	span.SetError(err)
+	if err != nil {
+		span.Finish()
+		return nil, nil, err
+	}
+	span.SetAttributes("repos", trending.Count())
+	span.Finish()
+	p.recorder().Add("repos_parsed", float64(trending.Count()))
+	p.log(ctx, "parse").Info("Found repositories", "repos", trending.Count())
+
+	var report *filter.Report
+	if p.Config.Filter != nil {
+		report = p.Config.Filter.Apply(trending)
+		for _, f := range report.Filtered {
+			p.log(ctx, "filter").Debug("Filtered out repository", "repo", f.Name, "reasons", strings.Join(f.Reasons, ", "))
+		}
+		p.log(ctx, "filter").Info("Filtered repositories", "filtered", len(report.Filtered), "kept", report.Kept)
+		p.recorder().Add("repos_filtered", float64(len(report.Filtered)))
+	}
+
+	if p.Config.Dedup {
+		trending.Dedup()
+	}
+
+	groups := groupRepos(trending)
+	found := p.lookup(ctx, githubNames(groups))
+	p.populateScreenshots(ctx, groups, found)
+	if p.Config.Enrich {
+		p.enrich(ctx, groups, found)
+	}
+	return trending, report, nil
+}
+
+// Daily returns the daily JSON file for the page of the given day, as Run would
+// publish it, without publishing anything (the repositories are still kept in the
+// store, if enabled). Unlike Run, it does not fall back to the previous day if the
+// page is missing.
+func (p *Pipeline) Daily(day time.Time) ([]byte, error) {
+	ctx := context.Background()
+	page, err := p.download(ctx, day)
+	if err != nil {
+		return nil, err
+	}
+	defer page.Close()
+
+	trending, _, err := p.collect(ctx, page)
+	if err != nil {
+		return nil, err
+	}
+	err = p.persist(ctx, day, trending)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(trending)
+}
+
+// Run visits the latest published Changelog Nightly page, extracts URLs to the
+// trending repositories in all three categories, prepares a JSON file with the
+// URLs and commits that file to a Github repository. The summary of the run is
+// sent to the notifier, if any, whether the run succeeded or not. All records
+// logged during the run carry the same run ID, and the run ends with a summary
+// record. The metrics of the run are flushed at its end.
+func (p *Pipeline) Run() error {
+	return p.RunContext(context.Background())
+}
+
+// RunContext is like Run, but sends all requests with the context. The run is
+// traced in a "run" span, a child of the span in ctx (if any), and its spans are
+// exported at its end.
+func (p *Pipeline) RunContext(ctx context.Context) error {
+	ctx, span := p.Tracer.Start(ctx, "run")
+	ctx = withLogger(ctx, p.Logger.With("run_id", newRunID()))
+	started := time.Now()
+
+	day := p.Config.Day.Day(p.Now())
+	trending, day, link, err := p.run(ctx, day)
+	ctx = withLogger(ctx, p.logger(ctx).With("date", day.Format("2006-01-02")))
+	p.notify(ctx, day, trending, link, err)
+	p.logSummary(ctx, trending, link, started, err)
+	p.flushMetrics(ctx, started, err)
+
+	span.SetAttributes("date", day.Format("2006-01-02"))
+	span.SetError(err)
+	span.Finish()
+	p.flushSpans(ctx)
+	return err
+}
+
+// run() processes and publishes the most recent published day, starting at day.
+// Returns the day processed, its trending repos and the link to the published
+// file. The trending repos are nil if the page could not be collected. Records
+// logged after the download carry the day processed.
+func (p *Pipeline) run(ctx context.Context, day time.Time) (*nightly.TrendingRepos, time.Time, string, error) {
+	// 1. Get HTML for the most recent published day
+	changelog, day, err := p.downloadLatest(ctx, day)
+	if err != nil {
+		return nil, day, "", err
+	}
+	defer changelog.Close()
+	ctx = withLogger(ctx, p.logger(ctx).With("date", day.Format("2006-01-02")))
+
+	// 2. Parse HTML, extract repository links and detect screenshots
+	trending, report, err := p.collect(ctx, changelog)
+	if err != nil {
+		return nil, day, "", err
+	}
+
+	// 3. Build a JSON file with the links
+	j, err := json.Marshal(trending)
+	if err != nil {
+		return trending, day, "", err
+	}
+
+	// 4. Add the file to the index and point the latest alias to it
+	data := newCommitData(trending, day)
+	todaysFileName, err := p.Config.path(data)
+	if err != nil {
+		return trending, day, "", err
+	}
+	idx, err := p.loadIndex(ctx, "")
+	if err != nil {
+		return trending, day, "", err
+	}
+	from, err := p.pendingBranch(ctx, idx, day)
+	if err != nil {
+		return trending, day, "", err
+	}
+	if from != "" {
+		idx, err = p.loadIndex(ctx, from)
+		if err != nil {
+			return trending, day, "", err
+		}
+	}
+	files, err := p.indexFiles(ctx, idx, trending, j, todaysFileName, day)
+	if err != nil {
+		return trending, day, "", err
+	}
+	files = append([]github.File{{Path: todaysFileName, Content: j}}, files...)
+	if report != nil {
+		files, err = appendReport(files, report, todaysFileName)
+		if err != nil {
+			return trending, day, "", err
+		}
+	}
+
+	// 5. Compare with the previous day, if enabled
+	if p.Config.Diff {
+		var diffFile *github.File
+		diffFile, data.Diff, err = p.diff(ctx, idx, from, trending, day, todaysFileName)
+		if err != nil {
+			return trending, day, "", err
+		}
+		if diffFile != nil {
+			files = append(files, *diffFile)
+		}
+	}
+
+	// 6. Upload the files
+	message, err := p.Config.message(data)
+	if err != nil {
+		return trending, day, "", err
+	}
+	link, err := p.publish(ctx, files, message, data, from)
+	if err != nil {
+		return trending, day, "", err
+	}
+
+	// 7. Keep the repositories in the store, if enabled
+	return trending, day, link, p.persist(ctx, day, trending)
+}
@@ -0,0 +1,229 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/github/githubtest"
+	"github.com/quasoft/changelog-nightly-parser/httpcache"
+)
+
+// testNow is the time returned by the clock of test pipelines, which process
+// the day before it (2018-02-08).
+var testNow = time.Date(2018, 2, 9, 6, 0, 0, 0, time.UTC)
+
+// testEnv() returns a getenv function for the settings of the user/trending-daily
+// repository, overridden by vars.
+func testEnv(vars map[string]string) func(string) string {
+	env := map[string]string{
+		"GITHUB_OWNER":      "user",
+		"GITHUB_REPOSITORY": "trending-daily",
+		"GITHUB_TOKEN":      "123",
+	}
+	for k, v := range vars {
+		env[k] = v
+	}
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+// testLogWriter writes log output of a pipeline to the test log.
+type testLogWriter struct {
+	t *testing.T
+}
+
+func (w testLogWriter) Write(b []byte) (int, error) {
+	w.t.Log(strings.TrimRight(string(b), "\n"))
+	return len(b), nil
+}
+
+// newStubGithub() starts a githubtest.Server for the user/trending-daily repository,
+// which is closed when the test completes.
+func newStubGithub(t *testing.T) *githubtest.Server {
+	stub := githubtest.NewServer("user", "trending-daily")
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+// newTestPipeline() returns a pipeline configured with the test environment overridden
+// by vars, downloading from a StubDownloader and uploading to the stub (if not nil).
+func newTestPipeline(t *testing.T, stub *githubtest.Server, vars map[string]string) *Pipeline {
+	cfg, err := LoadConfig(testEnv(vars))
+	if err != nil {
+		t.Fatalf("LoadConfig() failed with error: %v", err)
+	}
+
+	p := NewPipeline(cfg)
+	p.Downloader = NewStubDownloader()
+	p.Logger = NewLogger(testLogWriter{t}, "text", slog.LevelDebug)
+	p.Now = func() time.Time { return testNow }
+	if stub != nil {
+		p.Uploader = stub.Client()
+		p.GithubAPI = stub.URL
+	}
+	return p
+}
+
+func TestNewPipeline_Cache(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := LoadConfig(testEnv(map[string]string{"NIGHTLY_CACHE_DIR": t.TempDir()}))
+	if err != nil {
+		t.Fatalf("LoadConfig() failed with error: %v", err)
+	}
+
+	p := NewPipeline(cfg)
+	if _, ok := p.Downloader.(*http.Client).Transport.(*httpcache.Transport); !ok {
+		t.Errorf("Downloader does not cache responses, although NIGHTLY_CACHE_DIR is set")
+	}
+}
+
+func TestPipeline_DownloadFail(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPipeline(t, newStubGithub(t), nil)
+	p.Downloader.(*StubDownloader).errorToReturn = fmt.Errorf("unexpected error")
+	err := p.Run()
+	if err == nil {
+		t.Fatalf("Should have returned an error when download call fails.")
+	}
+}
+
+func TestPipeline_UploadFail(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPipeline(t, nil, nil)
+	p.Uploader = NewStubUploader()
+	p.Uploader.(*StubUploader).errorToReturn = fmt.Errorf("unexpected error")
+	err := p.Run()
+	if err == nil {
+		t.Fatalf("Should have returned an error when upload call fails.")
+	}
+
+	stub := newStubGithub(t)
+	stub.FailWrites = http.StatusBadRequest
+	p = newTestPipeline(t, stub, nil)
+	err = p.Run()
+	if err == nil {
+		t.Fatalf("Should have returned an error when upload request replies with an error.")
+	}
+}
+
+func TestPipeline_OK(t *testing.T) {
+	t.Parallel()
+
+	stub := newStubGithub(t)
+	err := newTestPipeline(t, stub, nil).Run()
+	if err != nil {
+		t.Fatalf("failed executing Pipeline in test. error: %v", err)
+	}
+
+	// Make sure all files were written in a single commit
+	gotCommits := stub.CommitCount("master")
+	wantCommits := 2
+	if gotCommits != wantCommits {
+		t.Errorf("Branch has %d commits, want %d", gotCommits, wantCommits)
+	}
+
+	for _, name := range []string{"2018-02-08.json", indexFileName, latestFileName} {
+		if _, ok := stub.File("master", name); !ok {
+			t.Errorf("%s was not committed", name)
+		}
+	}
+
+	// Make sure the committer is set
+	committer := stub.Head("master").Committer
+	if committer["name"] == "" || committer["email"] == "" {
+		t.Errorf("Commit has no committer name or email: %v", committer)
+	}
+
+	// Make sure the daily file contains at least one of the expected repositories
+	content, _ := stub.File("master", "2018-02-08.json")
+	want := "https://github.com/user1/repo1"
+	if !strings.Contains(string(content), want) {
+		t.Errorf("The file uploaded does not contain URL '%s', file: %s", want, content)
+	}
+	// Make sure the daily file contains the expected screenshot
+	want = "images/screenshot.jpg"
+	if !strings.Contains(string(content), want) {
+		t.Errorf("The file uploaded does not contain the expected screenshot URL: '%s', file: %s", want, content)
+	}
+}
+
+func TestPipeline_ContentsAPI(t *testing.T) {
+	t.Parallel()
+
+	stub := newStubGithub(t)
+	p := newTestPipeline(t, stub, map[string]string{"GITHUB_UPLOAD_API": "contents"})
+
+	err := p.Run()
+	if err != nil {
+		t.Fatalf("failed executing Pipeline in test. error: %v", err)
+	}
+
+	// One commit per file
+	gotCommits := stub.CommitCount("master")
+	wantCommits := 4
+	if gotCommits != wantCommits {
+		t.Errorf("Branch has %d commits, want %d", gotCommits, wantCommits)
+	}
+
+	// Running again on the same day updates the existing files
+	err = p.Run()
+	if err != nil {
+		t.Fatalf("failed updating existing files. error: %v", err)
+	}
+
+	content, _ := stub.File("master", indexFileName)
+	idx := Index{}
+	err = json.Unmarshal(content, &idx)
+	if err != nil {
+		t.Fatalf("Decoding index failed with error: %v", err)
+	}
+	if len(idx.Days) != 1 {
+		t.Errorf("Index has %d days, want %d", len(idx.Days), 1)
+	}
+}
+
+func TestPipeline_GithubApp(t *testing.T) {
+	t.Parallel()
+
+	stub := newStubGithub(t)
+	key := stub.NewAppKey("42")
+	stub.RequireToken = "installation-token-1"
+	stub.Now = func() time.Time { return testNow }
+
+	p := newTestPipeline(t, stub, map[string]string{
+		"GITHUB_TOKEN":               "",
+		"GITHUB_APP_ID":              "42",
+		"GITHUB_APP_INSTALLATION_ID": "1000",
+		// Lambda environment variables cannot contain new lines
+		"GITHUB_APP_PRIVATE_KEY": strings.Replace(string(key), "\n", `\n`, -1),
+	})
+
+	err := p.Run()
+	if err != nil {
+		t.Fatalf("failed executing Pipeline in test. error: %v", err)
+	}
+
+	path := "2018-02-08.json"
+	if _, ok := stub.File("master", path); !ok {
+		t.Errorf("%s was not committed", path)
+	}
+	if stub.TokenRequests != 1 {
+		t.Errorf("Installation token was requested %d times, want %d", stub.TokenRequests, 1)
+	}
+
+	// Readme lookups should use the installation token too
+	for _, auth := range p.Downloader.(*StubDownloader).authorization {
+		if auth != "token installation-token-1" {
+			t.Errorf("Readme was requested with authorization %q, want the installation token", auth)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+// maxPendingDays limits how many days back pendingBranch() looks for pull requests
+// that are still open.
+const maxPendingDays = 31
+
+// pullRequestBody() returns a Markdown summary of the day's counts, followed by
+// the comparison with the previous day if available, used as the body of the
+// pull request.
+func pullRequestBody(data CommitData) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Trending repositories from Changelog Nightly for %s.\n\n", data.Date)
+	fmt.Fprintf(&buf, "| Category | Repositories |\n")
+	fmt.Fprintf(&buf, "| --- | ---: |\n")
+	fmt.Fprintf(&buf, "| First timers | %d |\n", data.FirstTimers)
+	fmt.Fprintf(&buf, "| Top new | %d |\n", data.TopNew)
+	fmt.Fprintf(&buf, "| Repeat performers | %d |\n", data.RepeatPerformers)
+	fmt.Fprintf(&buf, "| **Total** | **%d** |\n", data.Total)
+	if data.Diff != "" {
+		fmt.Fprintf(&buf, "\n%s", data.Diff)
+	}
+	return buf.String()
+}
+
+// pendingBranch() returns the branch of the most recent pull request still open
+// for a day before day and after the latest day in idx (the index of the configured
+// branch), or "" if there is none. The days of unmerged pull requests are only in
+// their branches, so the next day has to build on them, or its index would drop
+// them and conflict with their pull requests on index.json and latest.json.
+// Branches are matched by the name rendered for each day, so the branch template
+// should only depend on the date. Pull requests merged automatically never stay
+// open, and are not looked up.
+func (p *Pipeline) pendingBranch(ctx context.Context, idx *Index, day time.Time) (string, error) {
+	if !p.Config.PullRequest || p.Config.AutoMerge {
+		return "", nil
+	}
+
+	gh := p.github(ctx)
+	base, err := gh.TargetBranch()
+	if err != nil {
+		return "", err
+	}
+	pulls, err := gh.OpenPullRequests(base)
+	if err != nil {
+		return "", err
+	}
+	open := map[string]bool{}
+	for _, pull := range pulls {
+		open[pull.Head.Ref] = true
+	}
+
+	// The index is sorted from the most recent day
+	latest := ""
+	if len(idx.Days) > 0 {
+		latest = idx.Days[0].Date
+	}
+	d := day
+	for i := 0; i < maxPendingDays; i++ {
+		d = previousDay(d)
+		if d.Format("2006-01-02") <= latest {
+			break
+		}
+		branch, err := p.Config.pullBranch(newCommitData(&nightly.TrendingRepos{}, d))
+		if err != nil {
+			return "", err
+		}
+		if open[branch] {
+			p.log(ctx, "publish").Info("Building on a pull request that is still open", "branch", branch)
+			return branch, nil
+		}
+	}
+	return "", nil
+}
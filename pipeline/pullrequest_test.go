@@ -0,0 +1,147 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPipeline_PullRequest(t *testing.T) {
+	t.Parallel()
+
+	stub := newStubGithub(t)
+	p := newTestPipeline(t, stub, map[string]string{
+		"GITHUB_PULL_REQUEST": "true",
+		"GITHUB_PULL_LABELS":  "trending, automated",
+	})
+
+	// Running twice on the same day should reuse the branch and pull request
+	for i := 0; i < 2; i++ {
+		err := p.Run()
+		if err != nil {
+			t.Fatalf("failed executing Pipeline in test. error: %v", err)
+		}
+	}
+
+	branch := "trending/2018-02-08"
+	path := "2018-02-08.json"
+
+	if _, ok := stub.File(branch, path); !ok {
+		t.Errorf("%s was not committed to branch %s", path, branch)
+	}
+	if _, ok := stub.File("master", path); ok {
+		t.Errorf("%s should not be committed to the default branch", path)
+	}
+
+	pulls := stub.Pulls()
+	if len(pulls) != 1 {
+		t.Fatalf("Opened %d pull requests, want %d", len(pulls), 1)
+	}
+	pull := pulls[0]
+	if pull.Head != branch || pull.Base != "master" {
+		t.Errorf("Pull request from %s to %s, want from %s to master", pull.Head, pull.Base, branch)
+	}
+	if pull.Title != "Uploading trending repos for 2018-02-08" {
+		t.Errorf("Pull request has title %q", pull.Title)
+	}
+	if !strings.Contains(pull.Body, "| **Total** | **4** |") {
+		t.Errorf("Pull request body does not summarise the counts: %s", pull.Body)
+	}
+	if strings.Join(pull.Labels, ",") != "trending,automated" {
+		t.Errorf("Pull request has labels %v", pull.Labels)
+	}
+	if pull.Merged {
+		t.Errorf("Pull request should not have been merged")
+	}
+}
+
+func TestPipeline_PullRequestUnmerged(t *testing.T) {
+	t.Parallel()
+
+	stub := newStubGithub(t)
+	vars := map[string]string{
+		"GITHUB_PULL_REQUEST": "true",
+		"NIGHTLY_DIFF":        "true",
+	}
+
+	// Two consecutive days, without merging the first pull request
+	for _, now := range []time.Time{testNow.AddDate(0, 0, -1), testNow} {
+		p := newTestPipeline(t, stub, vars)
+		p.Now = func() time.Time { return now }
+		err := p.Run()
+		if err != nil {
+			t.Fatalf("Run() failed with error: %v", err)
+		}
+	}
+
+	pulls := stub.Pulls()
+	if len(pulls) != 2 {
+		t.Fatalf("Opened %d pull requests, want %d", len(pulls), 2)
+	}
+
+	// The second day builds on the branch of the first one
+	branch := "trending/2018-02-08"
+	for _, path := range []string{"2018-02-07.json", "2018-02-08.json", "2018-02-08.diff.json"} {
+		if _, ok := stub.File(branch, path); !ok {
+			t.Errorf("%s is missing from branch %s", path, branch)
+		}
+	}
+
+	content, ok := stub.File(branch, indexFileName)
+	if !ok {
+		t.Fatalf("%s is missing from branch %s", indexFileName, branch)
+	}
+	idx := Index{}
+	err := json.Unmarshal(content, &idx)
+	if err != nil {
+		t.Fatalf("%s is not valid JSON: %v", indexFileName, err)
+	}
+	if len(idx.Days) != 2 || idx.Days[0].Date != "2018-02-08" || idx.Days[1].Date != "2018-02-07" {
+		t.Errorf("%s = %+v, want both days", indexFileName, idx.Days)
+	}
+
+	latest, _ := stub.File(branch, latestFileName)
+	daily, _ := stub.File(branch, "2018-02-08.json")
+	if string(latest) != string(daily) {
+		t.Errorf("%s on branch %s is not the second day: %s", latestFileName, branch, latest)
+	}
+}
+
+func TestPipeline_PullRequestAutoMerge(t *testing.T) {
+	t.Parallel()
+
+	stub := newStubGithub(t)
+	p := newTestPipeline(t, stub, map[string]string{
+		"GITHUB_PULL_REQUEST":    "true",
+		"GITHUB_PULL_BRANCH":     "daily-{{.Date}}",
+		"GITHUB_PULL_AUTO_MERGE": "true",
+	})
+
+	err := p.Run()
+	if err != nil {
+		t.Fatalf("failed executing Pipeline in test. error: %v", err)
+	}
+
+	pulls := stub.Pulls()
+	if len(pulls) != 1 || !pulls[0].Merged {
+		t.Fatalf("Pull request was not opened and merged")
+	}
+	if pulls[0].Head != "daily-2018-02-08" {
+		t.Errorf("Pull request branch = %s, want daily-2018-02-08", pulls[0].Head)
+	}
+
+	path := "2018-02-08.json"
+	if _, ok := stub.File("master", path); !ok {
+		t.Errorf("%s was not merged into the default branch", path)
+	}
+}
+
+func TestPullRequestBody_Diff(t *testing.T) {
+	t.Parallel()
+
+	body := pullRequestBody(CommitData{Date: "2018-02-08", Diff: "### Changes since 2018-02-07\n"})
+	if !strings.HasSuffix(body, "\n### Changes since 2018-02-07\n") {
+		t.Errorf("pullRequestBody() = %s, want the diff at the end", body)
+	}
+}
@@ -0,0 +1,135 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/github"
+	"github.com/quasoft/changelog-nightly-parser/metrics"
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+	"github.com/quasoft/changelog-nightly-parser/screenshot"
+)
+
+// readmeScreenshot() downloads the Markdown source of the default readme of the
+// repository from its host and returns the source of the image that looks like
+// a screenshot. Github readmes are requested raw (and authorized with the Github
+// token), so that they are the same as the readmes found by lookup(). Replies
+// other than 200 OK (eg. a missing readme or a rate limit) are returned as errors.
+func (p *Pipeline) readmeScreenshot(ctx context.Context, ref *nightly.RepoRef) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", ref.ReadmeURL(), nil)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := ref.Host.(nightly.GitHub); ok {
+		err = github.Authorize(req, p.tokens())
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Accept", "application/vnd.github.v3.raw")
+	}
+
+	resp, err := p.Downloader.Do(req)
+	if err != nil {
+		p.log(ctx, "screenshots").Warn("GET request for readme failed", "repo", ref.FullName(), "error", err)
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GET %s failed with status %d", ref.ReadmeURL(), resp.StatusCode)
+	}
+
+	text, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return screenshot.FromMarkdown(string(text)), nil
+}
+
+// findScreenshot() finds the first image that appears to be a screenshot in the
+// readme of the repository and returns the absolute URL to that image.
+// The readme is taken from info, if the repository was found by lookup(), or
+// downloaded otherwise.
+// Relative URLs are resolved against HEAD, the default branch on every host, so
+// that they are the same whether the readme was looked up or downloaded. The
+// search is traced in a "findScreenshot" span.
+func (p *Pipeline) findScreenshot(ctx context.Context, r *nightly.Repository, info *github.Repository) (absURL string, err error) {
+	ctx, span := p.Tracer.Start(ctx, "findScreenshot", "repo", r.URL)
+	defer func() {
+		span.SetAttributes("screenshot", absURL)
+		span.SetError(err)
+		span.Finish()
+	}()
+
+	ref, err := r.Ref()
+	if err != nil {
+		p.log(ctx, "screenshots").Warn("Could not get repository readme file", "repo", r.URL, "error", err)
+		return "", err
+	}
+
+	if info != nil {
+		// Repositories found by lookup() come with their readme, if they have one
+		if info.Readme != nil {
+			absURL = screenshot.FromMarkdown(info.Readme.Text)
+		}
+	} else {
+		// Download the default readme file
+		absURL, err = p.readmeScreenshot(ctx, ref)
+		if err != nil {
+			p.recorder().Add("readme_errors", 1)
+			p.log(ctx, "screenshots").Warn("Could not get repository readme file", "repo", r.URL, "error", err)
+			return "", err
+		}
+	}
+
+	if absURL == "" {
+		p.log(ctx, "screenshots").Debug("No screenshot detected", "repo", r.URL)
+		return "", fmt.Errorf("No screenshot detected")
+	}
+
+	if !strings.HasPrefix(absURL, strings.ToLower("http")) {
+		// If a relative URL was found, use the repository as a base URL
+		absURL = ref.RawURL("HEAD", absURL)
+	}
+	p.log(ctx, "screenshots").Debug("Screenshot chosen", "repo", r.URL, "screenshot", absURL)
+
+	return absURL, nil
+}
+
+// populateScreenshots() executes findScreenshot() once for each group of
+// repositories, with the readmes in found by lookup(), and sets the screenshot
+// of all repositories in the group.
+func (p *Pipeline) populateScreenshots(ctx context.Context, groups []*repoGroup, found map[string]*github.Repository) {
+	p.log(ctx, "screenshots").Info("Populating screenshots concurrently", "repos", len(groups))
+	defer metrics.Since(p.recorder(), "screenshots", time.Now())
+
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, 10)
+
+	for _, g := range groups {
+		g := g
+
+		limit <- struct{}{}
+		wg.Add(1)
+		go func() {
+			src, err := p.findScreenshot(ctx, g.repos[0], found[g.name])
+			if err == nil {
+				p.recorder().Add("screenshots_found", 1)
+				for _, r := range g.repos {
+					r.Screenshot = src
+				}
+			} else {
+				p.recorder().Add("screenshots_missing", 1)
+			}
+			<-limit
+			wg.Done()
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,102 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+func TestPipeline_findScreenshot(t *testing.T) {
+	p := newTestPipeline(t, nil, nil)
+
+	tests := []struct {
+		name           string
+		r              nightly.Repository
+		httpError      error
+		readmeHTML     string
+		wantErr        bool
+		wantScreenshot string
+	}{
+		{
+			"GET error",
+			nightly.Repository{URL: "https://github.com/user1/repo1"}, fmt.Errorf("HTTP Error"), ``,
+			true, "",
+		},
+		{
+			"No image",
+			nightly.Repository{URL: "https://github.com/user1/repo1"}, nil, `<p>Just text</p>`,
+			true, "",
+		},
+		{
+			"Relative image",
+			nightly.Repository{URL: "https://github.com/user1/repo1"}, nil, `<img src="screenshot.jpg">`,
+			false, "https://raw.githubusercontent.com/user1/repo1/HEAD/screenshot.jpg",
+		},
+		{
+			"Absolute image",
+			nightly.Repository{URL: "https://github.com/user1/repo1"}, nil, `<img src="http://example.com/demo.jpg">`,
+			false, "http://example.com/demo.jpg",
+		},
+		{
+			"GitLab Markdown readme",
+			nightly.Repository{URL: "https://gitlab.com/group/repo1"}, nil, "# Repo\n![Demo](docs/demo.png)",
+			false, "https://gitlab.com/group/repo1/-/raw/HEAD/docs/demo.png",
+		},
+		{
+			"Unsupported host",
+			nightly.Repository{URL: "https://bitbucket.org/user1/repo1"}, nil, `<img src="screenshot.jpg">`,
+			true, "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.Downloader.(*StubDownloader).errorToReturn = tt.httpError
+			p.Downloader.(*StubDownloader).body = bytes.NewBufferString(tt.readmeHTML)
+			screenshot, err := p.findScreenshot(context.Background(), &tt.r, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Pipeline.findScreenshot() error = %v, wantErr %v", err, tt.wantErr)
+			} else if screenshot != tt.wantScreenshot {
+				t.Errorf("Pipeline.findScreenshot() = %v, want %v", screenshot, tt.wantScreenshot)
+			}
+		})
+	}
+}
+
+func TestPipeline_findScreenshot_Status(t *testing.T) {
+	p := newTestPipeline(t, nil, nil)
+	p.Downloader.(*StubDownloader).statusCodeToReturn = http.StatusForbidden
+	p.Downloader.(*StubDownloader).body = bytes.NewBufferString(`{"message":"API rate limit exceeded"}`)
+
+	_, err := p.findScreenshot(context.Background(), &nightly.Repository{URL: "https://github.com/user1/repo1"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "status 403") {
+		t.Errorf("Pipeline.findScreenshot() error = %v, want the status of the readme request", err)
+	}
+}
+
+func TestPipeline_populateScreenshots_OncePerRepository(t *testing.T) {
+	p := newTestPipeline(t, nil, nil)
+	tr := &nightly.TrendingRepos{
+		First: []nightly.Repository{
+			{Name: "user1/repo1", URL: "https://github.com/user1/repo1"},
+			{Name: "user2/repo2", URL: "https://github.com/user2/repo2"},
+		},
+		Repeaters: []nightly.Repository{
+			{Name: "User1/Repo1", URL: "https://www.github.com/User1/Repo1/"},
+		},
+	}
+
+	p.populateScreenshots(context.Background(), groupRepos(tr), nil)
+
+	stub := p.Downloader.(*StubDownloader)
+	if len(stub.authorization) != 2 {
+		t.Errorf("Readmes were requested %d times, want once per repository", len(stub.authorization))
+	}
+	if tr.First[0].Screenshot == "" || tr.Repeaters[0].Screenshot != tr.First[0].Screenshot {
+		t.Errorf("Screenshots = %q and %q, want the same screenshot for both entries", tr.First[0].Screenshot, tr.Repeaters[0].Screenshot)
+	}
+}
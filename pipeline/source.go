@@ -0,0 +1,133 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/metrics"
+)
+
+const (
+	defaultSourceBaseURL      = "https://nightly.changelog.com/"
+	defaultSourcePathTemplate = "{{.Year}}/{{.Month}}/{{.Day}}"
+)
+
+// sourceData is the data available to the path template of the source page.
+type sourceData struct {
+	Date  string
+	Year  string
+	Month string
+	Day   string
+}
+
+// sourceURL() returns the URL of the Changelog Nightly page of the given day,
+// resolved against the configured base URL.
+func (cfg *Config) sourceURL(t time.Time) (string, error) {
+	var buf bytes.Buffer
+	err := cfg.SourcePathTemplate.Execute(&buf, sourceData{
+		Date:  t.Format("2006-01-02"),
+		Year:  t.Format("2006"),
+		Month: t.Format("01"),
+		Day:   t.Format("02"),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	ref, err := url.Parse(strings.TrimSpace(buf.String()))
+	if err != nil {
+		return "", fmt.Errorf("Source path template produced an invalid URL: %v", err)
+	}
+	return cfg.SourceBaseURL.ResolveReference(ref).String(), nil
+}
+
+// parseSourceBaseURL() parses the base URL of the source site, which must be an
+// absolute http or https URL. A trailing slash is added to the path if missing,
+// so that paths are resolved below it.
+func parseSourceBaseURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid NIGHTLY_BASE_URL %q: %v", s, err)
+	}
+	if (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("Invalid NIGHTLY_BASE_URL %q, expected an absolute http or https URL", s)
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return u, nil
+}
+
+// checkFinalURL() verifies where the request for the page of the day ended up after
+// redirects. Redirects to another host or from https to http are rejected. A redirect
+// to another page of the same site (eg. the home page) means that the page of the day
+// does not exist, so ErrPageNotFound is returned.
+func checkFinalURL(requested string, final *url.URL) error {
+	want, err := url.Parse(requested)
+	if err != nil {
+		return err
+	}
+
+	if final.Host != want.Host {
+		return fmt.Errorf("Request for %s was redirected to another host: %s", requested, final)
+	}
+	if want.Scheme == "https" && final.Scheme != "https" {
+		return fmt.Errorf("Request for %s was redirected to an insecure URL: %s", requested, final)
+	}
+	if strings.TrimRight(final.Path, "/") != strings.TrimRight(want.Path, "/") {
+		return ErrPageNotFound
+	}
+	return nil
+}
+
+// download() gets the Changelog Nightly page of the given day, in a "download"
+// span. Returns ErrPageNotFound if the page does not exist (yet).
+func (p *Pipeline) download(ctx context.Context, t time.Time) (page io.ReadCloser, err error) {
+	dateURL, err := p.Config.sourceURL(t)
+	if err != nil {
+		return nil, err
+	}
+
+	p.log(ctx, "download").Info("Getting data", "url", dateURL)
+	defer metrics.Since(p.recorder(), "download", time.Now())
+	ctx, span := p.Tracer.Start(ctx, "download", "url", dateURL)
+	defer func() {
+		span.SetError(err)
+		span.Finish()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", dateURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.Downloader.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes("status", resp.StatusCode)
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrPageNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("getting %s failed with status %d", dateURL, resp.StatusCode)
+	}
+
+	// http.Client sets the request to the last one made, after following redirects
+	if resp.Request != nil && resp.Request.URL != nil {
+		err = checkFinalURL(dateURL, resp.Request.URL)
+		if err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,85 @@
+package pipeline
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestConfig_sourceURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		vars map[string]string
+		want string
+	}{
+		{"Default", nil, "https://nightly.changelog.com/2018/02/08"},
+		{"Mirror", map[string]string{"NIGHTLY_BASE_URL": "http://localhost:8080/nightly"}, "http://localhost:8080/nightly/2018/02/08"},
+		{"Template", map[string]string{
+			"NIGHTLY_BASE_URL":      "https://archive.example.com/changelog/",
+			"NIGHTLY_PATH_TEMPLATE": "{{.Year}}/{{.Date}}.html",
+		}, "https://archive.example.com/changelog/2018/2018-02-08.html"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadConfig(testEnv(tt.vars))
+			if err != nil {
+				t.Fatalf("LoadConfig() failed with error: %v", err)
+			}
+			got, err := cfg.sourceURL(testNow.AddDate(0, 0, -1))
+			if err != nil {
+				t.Fatalf("cfg.sourceURL() failed with error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("cfg.sourceURL() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPipeline_download_Redirects(t *testing.T) {
+	t.Parallel()
+
+	requested := "https://nightly.changelog.com/2018/02/08"
+	tests := []struct {
+		name     string
+		final    string
+		wantErr  bool
+		notFound bool
+	}{
+		{"Trailing slash", "https://nightly.changelog.com/2018/02/08/", false, false},
+		{"Home page", "https://nightly.changelog.com/", true, true},
+		{"Other host", "https://example.com/2018/02/08", true, false},
+		{"Downgrade to http", "http://nightly.changelog.com/2018/02/08", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPipeline(t, nil, nil)
+			p.Downloader.(*StubDownloader).redirects = map[string]string{requested: tt.final}
+
+			page, err := p.download(context.Background(), testNow.AddDate(0, 0, -1))
+			if page != nil {
+				page.Close()
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("download() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (err == ErrPageNotFound) != tt.notFound {
+				t.Errorf("download() error = %v, want page not found %v", err, tt.notFound)
+			}
+		})
+	}
+}
+
+func TestPipeline_download_Status(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPipeline(t, nil, nil)
+	p.Downloader.(*StubDownloader).statusCodeToReturn = http.StatusInternalServerError
+
+	_, err := p.download(context.Background(), testNow)
+	if err == nil || err == ErrPageNotFound {
+		t.Errorf("download() error = %v, want an error for status 500", err)
+	}
+}
@@ -0,0 +1,31 @@
+package pipeline
+
+import (
+	"context"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+	"github.com/quasoft/changelog-nightly-parser/store"
+)
+
+// persist() stores the trending repositories of the day in the embedded store,
+// if a store file is configured. The store is opened only for the duration of
+// the call, so that it is not locked between runs.
+func (p *Pipeline) persist(ctx context.Context, day time.Time, trending *nightly.TrendingRepos) error {
+	if p.Config.StoreFile == "" {
+		return nil
+	}
+
+	s, err := store.Open(p.Config.StoreFile)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	err = s.PutDay(day, trending)
+	if err != nil {
+		return err
+	}
+	p.log(ctx, "store").Info("Stored repositories", "repos", trending.Count(), "file", p.Config.StoreFile)
+	return nil
+}
@@ -0,0 +1,39 @@
+package pipeline
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/quasoft/changelog-nightly-parser/store"
+)
+
+func TestPipeline_persist(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "trending.db")
+	stub := newStubGithub(t)
+	p := newTestPipeline(t, stub, map[string]string{"NIGHTLY_STORE_FILE": file})
+	err := p.Run()
+	if err != nil {
+		t.Fatalf("Run() failed with error: %v", err)
+	}
+
+	s, err := store.Open(file)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer s.Close()
+
+	days, err := s.Days()
+	if err != nil || len(days) != 1 || days[0].Format("2006-01-02") != "2018-02-08" {
+		t.Fatalf("Days() = %v, %v, want the processed day", days, err)
+	}
+	j, err := s.ExportJSON(days[0])
+	if err != nil {
+		t.Fatalf("ExportJSON() failed with error: %v", err)
+	}
+	daily, _ := stub.File("master", "2018-02-08.json")
+	if string(j) != string(daily) {
+		t.Errorf("ExportJSON() = %s, want the uploaded daily file %s", j, daily)
+	}
+}
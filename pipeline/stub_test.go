@@ -0,0 +1,191 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// sampleNightlyBody and sampleReadmeHTML are returned by StubDownloader for the
+// Changelog Nightly page and for readme files respectively.
+var (
+	sampleNightlyBody = readTestdata("../nightly/testdata/nightly.html")
+	sampleReadmeHTML  = readTestdata("testdata/readme.html")
+)
+
+func readTestdata(name string) string {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
+
+// StubDownloader is a stub implementation of the Downloader interface,
+// that creates an artificial response with status code 200 and content
+// equal to sampleNightlyBody
+type StubDownloader struct {
+	body               *bytes.Buffer
+	statusCodeToReturn int
+	errorToReturn      error
+	// notFound lists the URLs for which Get replies with 404.
+	notFound map[string]bool
+	// redirects maps URLs to the final URL Get pretends to be redirected to.
+	redirects map[string]string
+
+	mu            sync.Mutex
+	authorization []string
+	gets          []string
+}
+
+func NewStubDownloader() *StubDownloader {
+	return &StubDownloader{
+		body:               nil,
+		statusCodeToReturn: http.StatusOK,
+		errorToReturn:      nil,
+	}
+}
+
+func (s *StubDownloader) Get(url string) (*http.Response, error) {
+	s.mu.Lock()
+	s.gets = append(s.gets, url)
+	s.mu.Unlock()
+
+	if s.notFound[url] {
+		return &http.Response{
+			Status:     strconv.Itoa(http.StatusNotFound),
+			StatusCode: http.StatusNotFound,
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+			Header:     http.Header{},
+		}, s.errorToReturn
+	}
+
+	body := s.body
+	if body == nil {
+		body = bytes.NewBufferString(sampleNightlyBody)
+	}
+
+	final := url
+	if to, ok := s.redirects[url]; ok {
+		final = to
+	}
+	r, err := http.NewRequest("GET", final, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:     strconv.Itoa(s.statusCodeToReturn),
+		StatusCode: s.statusCodeToReturn,
+		Body:       ioutil.NopCloser(body),
+		Header:     http.Header{},
+		Request:    r,
+	}, s.errorToReturn
+}
+
+// Do replies to requests for nightly pages like Get, and to Github API requests
+// and readme requests with sampleReadmeHTML (or sampleNightlyBody, for
+// paths without "readme").
+func (s *StubDownloader) Do(r *http.Request) (*http.Response, error) {
+	switch {
+	case r.URL.Host == "github.com", r.URL.Host == "raw.githubusercontent.com", r.URL.Host == "gitlab.com":
+	case strings.HasPrefix(r.URL.Path, "/repos/"), strings.HasSuffix(r.URL.Path, "/graphql"):
+	default:
+		return s.Get(r.URL.String())
+	}
+
+	s.mu.Lock()
+	s.authorization = append(s.authorization, r.Header.Get("Authorization"))
+	s.mu.Unlock()
+
+	if strings.HasSuffix(r.URL.Path, "/graphql") {
+		// Pretend GraphQL is not available, so that readmes are requested one by one
+		return &http.Response{
+			Status:     strconv.Itoa(http.StatusNotFound),
+			StatusCode: http.StatusNotFound,
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+			Header:     http.Header{},
+		}, s.errorToReturn
+	}
+
+	body := s.body
+	if body == nil {
+		if strings.Contains(r.URL.Path, "readme") {
+			body = bytes.NewBufferString(sampleReadmeHTML)
+		} else {
+			body = bytes.NewBufferString(sampleNightlyBody)
+		}
+	}
+
+	return &http.Response{
+		Status:     strconv.Itoa(s.statusCodeToReturn),
+		StatusCode: s.statusCodeToReturn,
+		Body:       ioutil.NopCloser(body),
+		Header:     http.Header{},
+	}, s.errorToReturn
+}
+
+// StubUploader is a stub implementation of the github.Doer interface,
+// that replies to GET requests with the files stored in the files map
+// (or 404 if the file is not there) and records the body of other requests
+// in the puts map. Both maps are keyed by the name of the file.
+type StubUploader struct {
+	files              map[string][]byte
+	puts               map[string]*bytes.Buffer
+	statusCodeToReturn int
+	errorToReturn      error
+}
+
+func NewStubUploader() *StubUploader {
+	return &StubUploader{
+		files:              map[string][]byte{},
+		puts:               map[string]*bytes.Buffer{},
+		statusCodeToReturn: http.StatusCreated,
+		errorToReturn:      nil,
+	}
+}
+
+func (s *StubUploader) Do(r *http.Request) (*http.Response, error) {
+	name := path.Base(r.URL.Path)
+
+	if r.Method == "GET" {
+		content, ok := s.files[name]
+		if !ok {
+			return &http.Response{
+				Status:     strconv.Itoa(http.StatusNotFound),
+				StatusCode: http.StatusNotFound,
+				Body:       ioutil.NopCloser(strings.NewReader("")),
+				Header:     http.Header{},
+			}, s.errorToReturn
+		}
+
+		j, _ := json.Marshal(map[string]string{
+			"sha":     "sha-" + name,
+			"content": base64.StdEncoding.EncodeToString(content),
+		})
+		return &http.Response{
+			Status:     strconv.Itoa(http.StatusOK),
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader(j)),
+			Header:     http.Header{},
+		}, s.errorToReturn
+	}
+
+	body := bytes.NewBufferString("")
+	io.Copy(body, r.Body)
+	s.puts[name] = body
+
+	return &http.Response{
+		Status:     strconv.Itoa(s.statusCodeToReturn),
+		StatusCode: s.statusCodeToReturn,
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+		Header:     http.Header{},
+	}, s.errorToReturn
+}
@@ -0,0 +1,42 @@
+package pipeline
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/trace"
+)
+
+// traceTimeout limits the export of the spans of a run, so that an unreachable
+// collector does not hold up the end of the run.
+const traceTimeout = 10 * time.Second
+
+// newTracer() returns a tracer exporting spans as selected in the configuration,
+// or nil if tracing is disabled.
+func newTracer(cfg *Config) *trace.Tracer {
+	switch cfg.TraceExporter {
+	case "stdout":
+		return trace.NewTracer(&trace.Stdout{W: os.Stdout})
+	case "otlp":
+		return trace.NewTracer(&trace.OTLP{
+			URL:         strings.TrimRight(cfg.OTLPEndpoint, "/") + "/v1/traces",
+			ServiceName: cfg.ServiceName,
+			// The requests of the exporter are not traced themselves
+			Client: &http.Client{Timeout: traceTimeout},
+		})
+	default:
+		return nil
+	}
+}
+
+// flushSpans() exports the spans of the run with the context of the run. Export
+// errors are only logged, so that they do not fail the run.
+func (p *Pipeline) flushSpans(ctx context.Context) {
+	err := p.Tracer.Flush(ctx)
+	if err != nil {
+		p.log(ctx, "trace").Warn("Could not export spans", "error", err)
+	}
+}
@@ -0,0 +1,125 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/quasoft/changelog-nightly-parser/trace"
+)
+
+// collectedSpan is a span as received by the collector stand-in.
+type collectedSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Status       struct {
+		Code int `json:"code"`
+	} `json:"status"`
+}
+
+// newCollector() returns a stand-in for an OTLP/HTTP collector, and a function
+// returning the spans received so far.
+func newCollector(t *testing.T) (*httptest.Server, func() []collectedSpan) {
+	var mu sync.Mutex
+	spans := []collectedSpan{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []collectedSpan `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		mu.Lock()
+		defer mu.Unlock()
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []collectedSpan {
+		mu.Lock()
+		defer mu.Unlock()
+		return spans
+	}
+}
+
+func TestPipeline_Run_Trace(t *testing.T) {
+	t.Parallel()
+
+	collector, received := newCollector(t)
+	stub := newStubGithub(t)
+	p := newTestPipeline(t, stub, map[string]string{
+		"OTEL_TRACES_EXPORTER":        "otlp",
+		"OTEL_EXPORTER_OTLP_ENDPOINT": collector.URL,
+	})
+	p.Uploader = &http.Client{Transport: trace.NewTransport(p.Tracer, stub.Client().Transport)}
+	err := p.Run()
+	if err != nil {
+		t.Fatalf("Run() failed with error: %v", err)
+	}
+
+	spans := received()
+	byName := map[string][]collectedSpan{}
+	for _, s := range spans {
+		byName[s.Name] = append(byName[s.Name], s)
+	}
+	if len(byName["run"]) != 1 {
+		t.Fatalf("Received spans %v, want a single run span", spans)
+	}
+	run := byName["run"][0]
+	for _, s := range spans {
+		if s.TraceID != run.TraceID {
+			t.Errorf("Span %s is in trace %s, want %s", s.Name, s.TraceID, run.TraceID)
+		}
+	}
+	for name, count := range map[string]int{"download": 1, "parseNightlyPage": 1, "findScreenshot": 4, "uploadToGithub": 1} {
+		if len(byName[name]) != count {
+			t.Errorf("Received %d %s spans, want %d", len(byName[name]), name, count)
+		}
+		for _, s := range byName[name] {
+			if s.ParentSpanID != run.SpanID {
+				t.Errorf("Span %s has parent %s, want the run span %s", name, s.ParentSpanID, run.SpanID)
+			}
+		}
+	}
+
+	// Requests to Github are children of the upload span
+	upload := byName["uploadToGithub"][0].SpanID
+	requests := 0
+	for _, s := range byName["HTTP GET"] {
+		if s.ParentSpanID == upload {
+			requests++
+		}
+	}
+	if requests == 0 {
+		t.Errorf("No traced requests were sent during the upload")
+	}
+}
+
+func TestNewPipeline_Trace(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPipeline(t, nil, nil)
+	if p.Tracer != nil {
+		t.Errorf("Tracing should be disabled by default")
+	}
+	p = newTestPipeline(t, nil, map[string]string{"OTEL_TRACES_EXPORTER": "stdout"})
+	if _, ok := p.Tracer.Exporter.(*trace.Stdout); !ok {
+		t.Errorf("Exporter = %T, want stdout", p.Tracer.Exporter)
+	}
+	p = newTestPipeline(t, nil, map[string]string{"OTEL_TRACES_EXPORTER": "otlp"})
+	otlp, ok := p.Tracer.Exporter.(*trace.OTLP)
+	if !ok || otlp.Client.(*http.Client).Timeout != traceTimeout {
+		t.Errorf("Exporter = %#v, want OTLP with a client timeout", p.Tracer.Exporter)
+	}
+}
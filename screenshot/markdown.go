@@ -0,0 +1,56 @@
+package screenshot
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+)
+
+var (
+	// inlineImage matches ![alt](src "title") images.
+	inlineImage = regexp.MustCompile(`!\[([^\]]*)\]\(\s*<?([^)\s>]+)>?(?:\s+["'(][^)]*)?\)`)
+	// referenceImage matches ![alt][ref] and ![ref][] images.
+	referenceImage = regexp.MustCompile(`!\[([^\]]*)\]\[([^\]]*)\]`)
+	// referenceDefinition matches [ref]: src link definitions.
+	referenceDefinition = regexp.MustCompile(`(?m)^ {0,3}\[([^\]]+)\]:\s*<?([^\s>]+)>?`)
+)
+
+// FromMarkdown returns the source of the first image in a Markdown readme that
+// looks like a screenshot, or an empty string if none was found.
+// Markdown images are converted to <img> tags, so that they are detected the
+// same way as images in rendered readmes and as <img> tags embedded in the Markdown.
+func FromMarkdown(text string) string {
+	refs := map[string]string{}
+	for _, m := range referenceDefinition.FindAllStringSubmatch(text, -1) {
+		refs[strings.ToLower(m[1])] = m[2]
+	}
+
+	text = inlineImage.ReplaceAllStringFunc(text, func(s string) string {
+		m := inlineImage.FindStringSubmatch(s)
+		return imgTag(m[2], m[1])
+	})
+	text = referenceImage.ReplaceAllStringFunc(text, func(s string) string {
+		m := referenceImage.FindStringSubmatch(s)
+		ref := m[2]
+		if ref == "" {
+			ref = m[1]
+		}
+		src, ok := refs[strings.ToLower(ref)]
+		if !ok {
+			return s
+		}
+		return imgTag(src, m[1])
+	})
+
+	root, err := nethtml.Parse(strings.NewReader(text))
+	if err != nil {
+		return ""
+	}
+	return FromHTML(root)
+}
+
+func imgTag(src string, alt string) string {
+	return `<img src="` + html.EscapeString(src) + `" alt="` + html.EscapeString(alt) + `">`
+}
@@ -0,0 +1,44 @@
+package screenshot
+
+import "testing"
+
+func TestFromMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{
+			"No image",
+			"# Project\nA repository without images",
+			"",
+		},
+		{
+			"Badges and screenshot",
+			"[![Build Status](https://travis-ci.org/acme/rocket.svg)](https://travis-ci.org/acme/rocket)\n\n![Rocket](docs/rocket.png)",
+			"docs/rocket.png",
+		},
+		{
+			"Screenshot in alt text",
+			"![logo](logo.png)\n![Rocket](docs/first.png)\n![Screenshot](docs/second.png \"Title\")",
+			"docs/second.png",
+		},
+		{
+			"Reference image",
+			"![Demo][demo]\n\n[demo]: https://example.com/demo.gif",
+			"https://example.com/demo.gif",
+		},
+		{
+			"Embedded HTML image",
+			"# Project\n<p align=\"center\"><img src=\"media/screenshot.png\" width=\"600\"></p>",
+			"media/screenshot.png",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromMarkdown(tt.markdown); got != tt.want {
+				t.Errorf("FromMarkdown() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
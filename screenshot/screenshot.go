@@ -1,4 +1,6 @@
-package main
+// Package screenshot picks the image in a rendered readme that looks most like
+// a screenshot of the project, skipping badges, icons and logos.
+package screenshot
 
 import (
 	"bytes"
@@ -8,15 +10,17 @@ import (
 	"golang.org/x/net/html"
 )
 
-func screenshotFromHTML(parent *html.Node) string {
+// FromHTML returns the "src" attribute of the first image under parent that
+// looks like a screenshot, or an empty string if none was found.
+func FromHTML(parent *html.Node) string {
 	images := htmlquery.Find(parent, `//img`)
-	screenshot := screenshotFromImages(images)
+	screenshot := FromImages(images)
 	return screenshot
 }
 
-// screenshotFromImages() returns the first image that looks like a screenshot
+// FromImages returns the first image that looks like a screenshot
 // and returns the "src" attribute of that image as-is.
-func screenshotFromImages(images []*html.Node) string {
+func FromImages(images []*html.Node) string {
 	var src string
 	var screenshot *html.Node
 
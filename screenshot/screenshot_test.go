@@ -0,0 +1,109 @@
+package screenshot
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+
+	"github.com/quasoft/changelog-nightly-parser/internal/golden"
+)
+
+func TestFromHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			"No image",
+			`<p>A repository without images</p>`,
+			"",
+		},
+		{
+			"Only logo",
+			`<img src="images/some-logo.png">`,
+			"",
+		},
+		{
+			"Only badges and icons",
+			`<img src="images/badge.jpg"><img src="images/icon.jpg">`,
+			"",
+		},
+		{
+			"Single image",
+			`<img src="images/screenshot.jpg">`,
+			"images/screenshot.jpg",
+		},
+		{
+			"Badge and screenshot",
+			`<img src="images/badge.jpg"><img src="images/screenshot.jpg">`,
+			"images/screenshot.jpg",
+		},
+		{
+			"Emoji and screenshot",
+			`<img src="https://assets-cdn.github.com/images/icons/emoji/unicode/1f603.png"><img src="images/screenshot.jpg">`,
+			"images/screenshot.jpg",
+		},
+		{
+			"Demo",
+			`<img src="images/demo.jpg">`,
+			"images/demo.jpg",
+		},
+		{
+			"Sample",
+			`<img src="images/some-sample.jpg">`,
+			"images/some-sample.jpg",
+		},
+		{
+			"Example",
+			`<img src="images/some-example.jpg">`,
+			"images/some-example.jpg",
+		},
+		{
+			"Image with absolute URL",
+			`<img src="http://example.com/images/demo3.jpg">`,
+			"http://example.com/images/demo3.jpg",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := html.Parse(strings.NewReader(tt.html))
+			if err != nil {
+				t.Errorf("FromHTML() failed with error %v", err)
+			}
+
+			if got := FromHTML(root); got != tt.want {
+				t.Errorf("FromHTML() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// goldenArchive is the directory of the readmes recorded for the golden tests,
+// shared with the golden tests of the pipeline.
+const goldenArchive = "../pipeline/testdata/archive/api.github.com/repos"
+
+// TestFromMarkdown_Golden detects the screenshot in every readme recorded in
+// goldenArchive and compares it with testdata/golden/OWNER-REPO.golden. Run with
+// -update to regenerate the .golden files.
+func TestFromMarkdown_Golden(t *testing.T) {
+	readmes, err := filepath.Glob(filepath.Join(goldenArchive, "*", "*", "readme.md"))
+	if err != nil || len(readmes) == 0 {
+		t.Fatalf("No golden readmes found, error: %v", err)
+	}
+
+	for _, readme := range readmes {
+		rel, _ := filepath.Rel(goldenArchive, filepath.Dir(readme))
+		name := strings.Replace(filepath.ToSlash(rel), "/", "-", -1)
+		t.Run(name, func(t *testing.T) {
+			text, err := ioutil.ReadFile(readme)
+			if err != nil {
+				t.Fatalf("failed reading readme. error: %v", err)
+			}
+			golden.Assert(t, filepath.Join("testdata", "golden", name+".golden"), []byte(FromMarkdown(string(text))+"\n"))
+		})
+	}
+}
@@ -0,0 +1,259 @@
+// Package store persists the trending repositories of every day in an embedded
+// database (a single bbolt file, without cgo), so that the history can be
+// queried without reading all daily files.
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+// daysBucket contains a nested bucket for each day, named YYYY-MM-DD, with
+// the entries of the day keyed by category and lowercase repository name.
+var daysBucket = []byte("days")
+
+// categories are the names of the categories, in the order of the nightly page.
+var categories = []string{nightly.FirstTimers, nightly.TopNew, nightly.RepeatPerformers}
+
+// Entry is a repository listed in a category on a day.
+type Entry struct {
+	Date     string `json:"Date"`
+	Category string `json:"Category"`
+	// Rank is the position of the repository in the category, starting from 1.
+	Rank       int                `json:"Rank"`
+	Repository nightly.Repository `json:"Repository"`
+}
+
+// Query selects entries. Zero fields match all entries.
+type Query struct {
+	// From and To limit the entries to a range of days, inclusive.
+	From time.Time
+	To   time.Time
+	// Language matches the language of the repository, case-insensitively.
+	Language string
+	// Owner matches the owner of the repository, case-insensitively.
+	Owner string
+	// Category matches the category of the entry.
+	Category string
+}
+
+// matches() reports whether the entry is selected by the query, ignoring the date.
+func (q *Query) matches(e *Entry) bool {
+	if q.Language != "" && !strings.EqualFold(q.Language, e.Repository.Language) {
+		return false
+	}
+	if q.Owner != "" && !strings.EqualFold(q.Owner, strings.SplitN(e.Repository.Name, "/", 2)[0]) {
+		return false
+	}
+	if q.Category != "" && q.Category != e.Category {
+		return false
+	}
+	return true
+}
+
+// Store is a database of trending repositories.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens the database in the file at path, creating it if it doesn't exist.
+// The store should be closed with Close when no longer used. Only one process
+// can open the file at a time.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: 10 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("Could not open store %s: %v", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(daysBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// entryKey() returns the key of the repository in a category.
+func entryKey(category string, name string) []byte {
+	return []byte(category + "/" + strings.ToLower(name))
+}
+
+// PutDay stores the repositories of the day, replacing any repositories stored
+// for the same day before, so that a day can be stored again after reprocessing it.
+func (s *Store) PutDay(date time.Time, tr *nightly.TrendingRepos) error {
+	day := date.Format("2006-01-02")
+	return s.db.Update(func(tx *bolt.Tx) error {
+		days := tx.Bucket(daysBucket)
+		if days.Bucket([]byte(day)) != nil {
+			err := days.DeleteBucket([]byte(day))
+			if err != nil {
+				return err
+			}
+		}
+		b, err := days.CreateBucket([]byte(day))
+		if err != nil {
+			return err
+		}
+
+		all := [][]nightly.Repository{tr.First, tr.New, tr.Repeaters}
+		for cat := range all {
+			for i, r := range all[cat] {
+				e := Entry{Date: day, Category: categories[cat], Rank: i + 1, Repository: r}
+				j, err := json.Marshal(e)
+				if err != nil {
+					return err
+				}
+				err = b.Put(entryKey(e.Category, r.Name), j)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Find returns the entries selected by the query, sorted by day, category
+// (in the order of the nightly page) and rank.
+func (s *Store) Find(q Query) ([]Entry, error) {
+	entries := []Entry{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(daysBucket).Cursor()
+
+		k, _ := c.First()
+		if !q.From.IsZero() {
+			k, _ = c.Seek([]byte(q.From.Format("2006-01-02")))
+		}
+		for ; k != nil; k, _ = c.Next() {
+			if !q.To.IsZero() && string(k) > q.To.Format("2006-01-02") {
+				break
+			}
+
+			day := []Entry{}
+			err := c.Bucket().Bucket(k).ForEach(func(_, v []byte) error {
+				e := Entry{}
+				err := json.Unmarshal(v, &e)
+				if err != nil {
+					return err
+				}
+				if q.matches(&e) {
+					day = append(day, e)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			sortEntries(day)
+			entries = append(entries, day...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// sortEntries() sorts the entries of a day by category and rank.
+func sortEntries(entries []Entry) {
+	order := map[string]int{}
+	for i, cat := range categories {
+		order[cat] = i
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Category != b.Category {
+			return order[a.Category] < order[b.Category]
+		}
+		return a.Rank < b.Rank
+	})
+}
+
+// ByLanguage returns the entries of repositories written in the language.
+func (s *Store) ByLanguage(language string) ([]Entry, error) {
+	return s.Find(Query{Language: language})
+}
+
+// ByOwner returns the entries of repositories of the owner.
+func (s *Store) ByOwner(owner string) ([]Entry, error) {
+	return s.Find(Query{Owner: owner})
+}
+
+// Between returns the entries of the days from first to last, inclusive.
+func (s *Store) Between(first time.Time, last time.Time) ([]Entry, error) {
+	return s.Find(Query{From: first, To: last})
+}
+
+// Days returns the days stored, from the oldest to the most recent one.
+func (s *Store) Days() ([]time.Time, error) {
+	days := []time.Time{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(daysBucket).ForEach(func(k, _ []byte) error {
+			date, err := time.Parse("2006-01-02", string(k))
+			if err != nil {
+				return err
+			}
+			days = append(days, date)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return days, nil
+}
+
+// Export returns the repositories stored for the day, in the structure of
+// the daily files. Returns nil if the day is not stored.
+func (s *Store) Export(date time.Time) (*nightly.TrendingRepos, error) {
+	entries, err := s.Between(date, date)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	tr := &nightly.TrendingRepos{First: []nightly.Repository{}, New: []nightly.Repository{}, Repeaters: []nightly.Repository{}}
+	for _, e := range entries {
+		switch e.Category {
+		case nightly.FirstTimers:
+			tr.First = append(tr.First, e.Repository)
+		case nightly.TopNew:
+			tr.New = append(tr.New, e.Repository)
+		case nightly.RepeatPerformers:
+			tr.Repeaters = append(tr.Repeaters, e.Repository)
+		}
+	}
+	return tr, nil
+}
+
+// ExportJSON returns the daily file of the day, as uploaded by the pipeline.
+// Returns an error if the day is not stored.
+func (s *Store) ExportJSON(date time.Time) ([]byte, error) {
+	tr, err := s.Export(date)
+	if err != nil {
+		return nil, err
+	}
+	if tr == nil {
+		return nil, fmt.Errorf("Day %s not found in store", date.Format("2006-01-02"))
+	}
+	return json.Marshal(tr)
+}
@@ -0,0 +1,171 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/nightly"
+)
+
+func date(s string) time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return d
+}
+
+// newTestStore() returns a store with three days of repositories.
+func newTestStore(t *testing.T) *Store {
+	s, err := Open(filepath.Join(t.TempDir(), "trending.db"))
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	days := map[string]*nightly.TrendingRepos{
+		"2018-02-08": {
+			First: []nightly.Repository{{Name: "acme/rocket", Language: "Go", Stars: 100}, {Name: "jane/notes", Language: "Rust"}},
+		},
+		"2018-02-09": {
+			New:       []nightly.Repository{{Name: "jane/tasks", Language: "go"}},
+			Repeaters: []nightly.Repository{{Name: "acme/rocket", Language: "Go", Stars: 200}},
+		},
+		"2018-02-10": {
+			Repeaters: []nightly.Repository{{Name: "Acme/Jet", Language: "Python"}},
+		},
+	}
+	for day, tr := range days {
+		err := s.PutDay(date(day), tr)
+		if err != nil {
+			t.Fatalf("PutDay() failed with error: %v", err)
+		}
+	}
+	return s
+}
+
+func TestStore_Find(t *testing.T) {
+	t.Parallel()
+
+	s := newTestStore(t)
+	tests := []struct {
+		name string
+		q    Query
+		want []string
+	}{
+		{"All", Query{}, []string{"2018-02-08 acme/rocket", "2018-02-08 jane/notes", "2018-02-09 jane/tasks", "2018-02-09 acme/rocket", "2018-02-10 Acme/Jet"}},
+		{"Language", Query{Language: "GO"}, []string{"2018-02-08 acme/rocket", "2018-02-09 jane/tasks", "2018-02-09 acme/rocket"}},
+		{"Owner", Query{Owner: "acme"}, []string{"2018-02-08 acme/rocket", "2018-02-09 acme/rocket", "2018-02-10 Acme/Jet"}},
+		{"Range", Query{From: date("2018-02-09"), To: date("2018-02-09")}, []string{"2018-02-09 jane/tasks", "2018-02-09 acme/rocket"}},
+		{"Open range", Query{From: date("2018-02-10")}, []string{"2018-02-10 Acme/Jet"}},
+		{"Category", Query{Category: nightly.RepeatPerformers, Owner: "acme"}, []string{"2018-02-09 acme/rocket", "2018-02-10 Acme/Jet"}},
+		{"No match", Query{Language: "COBOL"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := s.Find(tt.q)
+			if err != nil {
+				t.Fatalf("Find() failed with error: %v", err)
+			}
+			got := []string{}
+			for _, e := range entries {
+				got = append(got, e.Date+" "+e.Repository.Name)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Find() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Find() = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestStore_PutDay_Replace(t *testing.T) {
+	t.Parallel()
+
+	s := newTestStore(t)
+	tr := &nightly.TrendingRepos{
+		First:     []nightly.Repository{{Name: "jane/notes", Language: "Rust", Stars: 150}},
+		New:       []nightly.Repository{},
+		Repeaters: []nightly.Repository{},
+	}
+	err := s.PutDay(date("2018-02-08"), tr)
+	if err != nil {
+		t.Fatalf("PutDay() failed with error: %v", err)
+	}
+
+	entries, err := s.Between(date("2018-02-08"), date("2018-02-08"))
+	if err != nil {
+		t.Fatalf("Between() failed with error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Repository.Name != "jane/notes" || entries[0].Rank != 1 || entries[0].Repository.Stars != 150 {
+		t.Errorf("Between() = %+v, want only jane/notes, ranked first", entries)
+	}
+
+	got, err := s.ExportJSON(date("2018-02-08"))
+	if err != nil {
+		t.Fatalf("ExportJSON() failed with error: %v", err)
+	}
+	want, _ := json.Marshal(tr)
+	if !bytes.Equal(got, want) {
+		t.Errorf("ExportJSON() = %s, want %s", got, want)
+	}
+}
+
+func TestStore_ExportJSON(t *testing.T) {
+	t.Parallel()
+
+	daily, err := ioutil.ReadFile("../pipeline/testdata/golden/2018-02-08.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := &nightly.TrendingRepos{}
+	err = json.Unmarshal(daily, tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestStore(t)
+	err = s.PutDay(date("2018-02-07"), tr)
+	if err != nil {
+		t.Fatalf("PutDay() failed with error: %v", err)
+	}
+
+	got, err := s.ExportJSON(date("2018-02-07"))
+	if err != nil {
+		t.Fatalf("ExportJSON() failed with error: %v", err)
+	}
+	want, _ := json.Marshal(tr)
+	if !bytes.Equal(got, want) {
+		t.Errorf("ExportJSON() = %s, want %s", got, want)
+	}
+
+	_, err = s.ExportJSON(date("2018-01-01"))
+	if err == nil {
+		t.Errorf("ExportJSON() should have failed for a day that is not stored")
+	}
+
+	days, err := s.Days()
+	if err != nil || len(days) != 4 || !days[0].Equal(date("2018-02-07")) {
+		t.Errorf("Days() = %v, %v", days, err)
+	}
+}
+
+func TestStore_ByLanguageAndOwner(t *testing.T) {
+	t.Parallel()
+
+	s := newTestStore(t)
+	entries, err := s.ByLanguage("rust")
+	if err != nil || len(entries) != 1 || entries[0].Repository.Name != "jane/notes" {
+		t.Errorf("ByLanguage() = %+v, %v", entries, err)
+	}
+	entries, err = s.ByOwner("jane")
+	if err != nil || len(entries) != 2 {
+		t.Errorf("ByOwner() = %+v, %v", entries, err)
+	}
+}
@@ -0,0 +1,186 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/quasoft/changelog-nightly-parser/github"
+)
+
+// Stdout is an exporter writing each span as a JSON line.
+type Stdout struct {
+	W io.Writer
+}
+
+type stdoutSpan struct {
+	Name       string                 `json:"name"`
+	TraceID    string                 `json:"trace_id"`
+	SpanID     string                 `json:"span_id"`
+	ParentID   string                 `json:"parent_span_id,omitempty"`
+	Start      time.Time              `json:"start"`
+	End        time.Time              `json:"end"`
+	Duration   float64                `json:"duration_ms"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// Export writes the spans to W. The context is not used.
+func (e *Stdout) Export(ctx context.Context, spans []*Span) error {
+	enc := json.NewEncoder(e.W)
+	for _, s := range spans {
+		out := stdoutSpan{
+			Name:     s.Name,
+			TraceID:  s.TraceID.String(),
+			SpanID:   s.SpanID.String(),
+			Start:    s.Start,
+			End:      s.End,
+			Duration: float64(s.End.Sub(s.Start)) / float64(time.Millisecond),
+			Error:    s.Error,
+		}
+		if s.ParentID.IsValid() {
+			out.ParentID = s.ParentID.String()
+		}
+		if len(s.Attributes) > 0 {
+			out.Attributes = map[string]interface{}{}
+			for _, a := range s.Attributes {
+				out.Attributes[a.Key] = a.Value
+			}
+		}
+		err := enc.Encode(out)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OTLP is an exporter posting the spans to an OpenTelemetry collector, with the
+// JSON encoding of the OTLP/HTTP protocol.
+type OTLP struct {
+	// URL is the traces endpoint of the collector (eg. "http://localhost:4318/v1/traces").
+	URL string
+	// ServiceName is the service.name resource attribute of the spans.
+	ServiceName string
+	// Client sends the requests. It must not trace its own requests.
+	Client github.Doer
+}
+
+// The OTLP JSON encoding, see opentelemetry-proto. IDs are hex encoded and
+// 64-bit integers are strings.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Name              string         `json:"name"`
+		Kind              Kind           `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Status            otlpStatus     `json:"status"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string                 `json:"key"`
+		Value map[string]interface{} `json:"value"`
+	}
+)
+
+// otlpStatusError is the status code of failed spans.
+const otlpStatusError = 2
+
+// otlpValue() returns the OTLP AnyValue of v.
+func otlpValue(v interface{}) map[string]interface{} {
+	switch v := v.(type) {
+	case string:
+		return map[string]interface{}{"stringValue": v}
+	case bool:
+		return map[string]interface{}{"boolValue": v}
+	case int:
+		return map[string]interface{}{"intValue": strconv.Itoa(v)}
+	case int64:
+		return map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+	case float64:
+		return map[string]interface{}{"doubleValue": v}
+	default:
+		return map[string]interface{}{"stringValue": fmt.Sprint(v)}
+	}
+}
+
+// Export posts the spans to the collector, with the context.
+func (e *OTLP) Export(ctx context.Context, spans []*Span) error {
+	scope := otlpScopeSpans{Scope: otlpScope{Name: "github.com/quasoft/changelog-nightly-parser/trace"}}
+	for _, s := range spans {
+		span := otlpSpan{
+			TraceID:           s.TraceID.String(),
+			SpanID:            s.SpanID.String(),
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+		}
+		if s.ParentID.IsValid() {
+			span.ParentSpanID = s.ParentID.String()
+		}
+		for _, a := range s.Attributes {
+			span.Attributes = append(span.Attributes, otlpKeyValue{a.Key, otlpValue(a.Value)})
+		}
+		if s.Error != "" {
+			span.Status = otlpStatus{Code: otlpStatusError, Message: s.Error}
+		}
+		scope.Spans = append(scope.Spans, span)
+	}
+	body := otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpKeyValue{{"service.name", otlpValue(e.ServiceName)}}},
+		ScopeSpans: []otlpScopeSpans{scope},
+	}}}
+
+	j, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	r, err := http.NewRequestWithContext(ctx, "POST", e.URL, bytes.NewReader(j))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.Client.Do(r)
+	if err != nil {
+		return fmt.Errorf("Exporting spans to %s failed with error: %v", e.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("Exporting spans to %s failed with status %d, msg: %s", e.URL, resp.StatusCode, string(msg))
+	}
+	return nil
+}
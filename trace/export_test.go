@@ -0,0 +1,111 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newCollector() returns a stand-in for an OTLP/HTTP collector, replying with
+// the status and keeping the requests posted to /v1/traces.
+func newCollector(t *testing.T, status int) (*httptest.Server, *[]otlpRequest) {
+	requests := &[]otlpRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Collector received %s %s (%s)", r.Method, r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		req := otlpRequest{}
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			t.Errorf("Collector received invalid JSON: %v", err)
+		}
+		*requests = append(*requests, req)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func TestOTLP_Export(t *testing.T) {
+	t.Parallel()
+
+	collector, requests := newCollector(t, http.StatusOK)
+	tracer := NewTracer(&OTLP{URL: collector.URL + "/v1/traces", ServiceName: "nightly", Client: collector.Client()})
+	ctx, root := tracer.Start(context.Background(), "run")
+	_, child := tracer.Start(ctx, "download", "status", 404, "cached", true)
+	child.SetError(fmt.Errorf("page not found"))
+	child.Finish()
+	root.Finish()
+
+	err := tracer.Flush(context.Background())
+	if err != nil {
+		t.Fatalf("Flush() failed with error: %v", err)
+	}
+	if len(*requests) != 1 {
+		t.Fatalf("Collector received %d requests, want 1", len(*requests))
+	}
+	rs := (*requests)[0].ResourceSpans
+	if len(rs) != 1 || rs[0].Resource.Attributes[0].Key != "service.name" || rs[0].Resource.Attributes[0].Value["stringValue"] != "nightly" {
+		t.Fatalf("Resource spans = %+v, want the service name", rs)
+	}
+	spans := rs[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("Collector received %d spans, want 2", len(spans))
+	}
+	got := spans[0]
+	if got.Name != "download" || got.TraceID != root.TraceID.String() || got.ParentSpanID != root.SpanID.String() ||
+		got.Kind != Internal || got.StartTimeUnixNano == "" || got.Status.Code != otlpStatusError || got.Status.Message != "page not found" {
+		t.Errorf("Span = %+v", got)
+	}
+	if len(got.Attributes) != 2 || got.Attributes[0].Value["intValue"] != "404" || got.Attributes[1].Value["boolValue"] != true {
+		t.Errorf("Attributes = %+v", got.Attributes)
+	}
+	if spans[1].ParentSpanID != "" || spans[1].Status.Code != 0 {
+		t.Errorf("Root span = %+v", spans[1])
+	}
+}
+
+func TestOTLP_Export_Fail(t *testing.T) {
+	t.Parallel()
+
+	collector, _ := newCollector(t, http.StatusServiceUnavailable)
+	tracer := NewTracer(&OTLP{URL: collector.URL + "/v1/traces", Client: collector.Client()})
+	_, span := tracer.Start(context.Background(), "run")
+	span.Finish()
+
+	err := tracer.Flush(context.Background())
+	if err == nil {
+		t.Errorf("Flush() should have failed when the collector replies with an error")
+	}
+}
+
+func TestOTLP_Export_Timeout(t *testing.T) {
+	t.Parallel()
+
+	// The collector never replies
+	release := make(chan struct{})
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(collector.Close)
+	t.Cleanup(func() { close(release) })
+
+	tracer := NewTracer(&OTLP{URL: collector.URL + "/v1/traces", Client: collector.Client()})
+	_, span := tracer.Start(context.Background(), "run")
+	span.Finish()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := tracer.Flush(ctx)
+	if err == nil || !strings.Contains(err.Error(), "deadline exceeded") {
+		t.Errorf("Flush() error = %v, want the context deadline", err)
+	}
+}
@@ -0,0 +1,194 @@
+// Package trace records spans of the stages of a run and exports them to stdout
+// or to an OpenTelemetry collector (OTLP over HTTP). Spans are carried in
+// contexts, and Transport propagates them to outgoing HTTP requests with the
+// W3C traceparent header.
+//
+// All methods are safe to call on a nil *Tracer and a nil *Span, in which case
+// nothing is recorded, so that tracing can be disabled by not creating a tracer.
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TraceID identifies a trace, SpanID a span within it.
+type (
+	TraceID [16]byte
+	SpanID  [8]byte
+)
+
+// String returns the ID as lowercase hex.
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// String returns the ID as lowercase hex.
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsValid reports whether the ID is set.
+func (id SpanID) IsValid() bool { return id != SpanID{} }
+
+// Kind tells whether a span is an operation of the process (Internal) or a
+// request to another service (Client), with the values used by OTLP.
+type Kind int
+
+const (
+	Internal Kind = 1
+	Client   Kind = 3
+)
+
+// Attribute is a key-value pair describing a span.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Span is a timed operation within a trace.
+type Span struct {
+	Name     string
+	Kind     Kind
+	TraceID  TraceID
+	SpanID   SpanID
+	ParentID SpanID
+	Start    time.Time
+	End      time.Time
+	// Attributes are kept in the order they were set.
+	Attributes []Attribute
+	// Error is the error the operation failed with, or empty if it succeeded.
+	Error string
+
+	tracer *Tracer
+	mu     sync.Mutex
+	ended  bool
+}
+
+// SetAttributes adds attributes to the span, given as alternating keys and
+// values, like slog: span.SetAttributes("repo", name, "status", 200).
+func (s *Span) SetAttributes(kv ...interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Attributes = appendAttributes(s.Attributes, kv)
+}
+
+// SetError marks the span as failed with err, if err is not nil.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Error = err.Error()
+}
+
+// Finish ends the span and queues it for export by its tracer. Calls after
+// the first one are ignored.
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.End = s.tracer.now()
+	s.mu.Unlock()
+	s.tracer.finished(s)
+}
+
+// Traceparent returns the W3C traceparent header identifying the span.
+func (s *Span) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-01", s.TraceID, s.SpanID)
+}
+
+// appendAttributes() appends the alternating keys and values in kv to attrs.
+func appendAttributes(attrs []Attribute, kv []interface{}) []Attribute {
+	for i := 0; i+1 < len(kv); i += 2 {
+		attrs = append(attrs, Attribute{Key: fmt.Sprint(kv[i]), Value: kv[i+1]})
+	}
+	return attrs
+}
+
+// Exporter sends finished spans somewhere, giving up when ctx is done.
+type Exporter interface {
+	Export(ctx context.Context, spans []*Span) error
+}
+
+// Tracer creates spans and exports them in batches with the exporter.
+type Tracer struct {
+	Exporter Exporter
+	// Now returns the start and end times of spans.
+	Now func() time.Time
+
+	mu   sync.Mutex
+	done []*Span
+}
+
+// NewTracer returns a tracer exporting spans with the exporter.
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{Exporter: exporter, Now: time.Now}
+}
+
+type spanKey struct{}
+
+// FromContext returns the span carried by the context, or nil if none.
+func FromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// Start starts a span named name, as a child of the span in ctx (if any), and
+// returns a context carrying the new span. The span has to be ended with Finish.
+func (t *Tracer) Start(ctx context.Context, name string, kv ...interface{}) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	s := &Span{Name: name, Kind: Internal, Start: t.now(), tracer: t}
+	if parent := FromContext(ctx); parent != nil {
+		s.TraceID = parent.TraceID
+		s.ParentID = parent.SpanID
+	} else {
+		rand.Read(s.TraceID[:])
+	}
+	rand.Read(s.SpanID[:])
+	s.Attributes = appendAttributes(nil, kv)
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+func (t *Tracer) now() time.Time {
+	if t.Now == nil {
+		return time.Now()
+	}
+	return t.Now()
+}
+
+// finished() queues the span for export.
+func (t *Tracer) finished(s *Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.done = append(t.done, s)
+}
+
+// Flush exports the spans finished since the last flush, with the context.
+func (t *Tracer) Flush(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	spans := t.done
+	t.done = nil
+	t.mu.Unlock()
+
+	if len(spans) == 0 || t.Exporter == nil {
+		return nil
+	}
+	return t.Exporter.Export(ctx, spans)
+}
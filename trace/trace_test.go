@@ -0,0 +1,112 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memoryExporter keeps the exported spans.
+type memoryExporter struct {
+	spans []*Span
+}
+
+func (e *memoryExporter) Export(ctx context.Context, spans []*Span) error {
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func TestTracer_Start(t *testing.T) {
+	t.Parallel()
+
+	exporter := &memoryExporter{}
+	tracer := NewTracer(exporter)
+	ctx, root := tracer.Start(context.Background(), "run", "date", "2018-02-08")
+	if FromContext(ctx) != root {
+		t.Fatalf("FromContext() did not return the started span")
+	}
+	_, child := tracer.Start(ctx, "download")
+	child.SetError(fmt.Errorf("not found"))
+	child.Finish()
+	child.Finish()
+	root.Finish()
+
+	if child.TraceID != root.TraceID || child.ParentID != root.SpanID || child.SpanID == root.SpanID {
+		t.Errorf("Child span %s/%s (parent %s) is not a child of %s/%s", child.TraceID, child.SpanID, child.ParentID, root.TraceID, root.SpanID)
+	}
+	if root.ParentID.IsValid() {
+		t.Errorf("Root span has parent %s", root.ParentID)
+	}
+
+	err := tracer.Flush(context.Background())
+	if err != nil {
+		t.Fatalf("Flush() failed with error: %v", err)
+	}
+	if len(exporter.spans) != 2 || exporter.spans[0] != child || exporter.spans[1] != root {
+		t.Fatalf("Exported %v, want each span once, in the order they ended", exporter.spans)
+	}
+	if child.Error != "not found" || len(root.Attributes) != 1 || root.Attributes[0] != (Attribute{"date", "2018-02-08"}) {
+		t.Errorf("Spans have error %q and attributes %v", child.Error, root.Attributes)
+	}
+
+	// Spans are only exported once
+	exporter.spans = nil
+	tracer.Flush(context.Background())
+	if len(exporter.spans) != 0 {
+		t.Errorf("Flush() exported %d spans again", len(exporter.spans))
+	}
+}
+
+func TestTracer_Nil(t *testing.T) {
+	t.Parallel()
+
+	var tracer *Tracer
+	ctx, span := tracer.Start(context.Background(), "run")
+	if span != nil || FromContext(ctx) != nil {
+		t.Fatalf("Nil tracer started span %v", span)
+	}
+	span.SetAttributes("repo", "acme/rocket")
+	span.SetError(fmt.Errorf("failed"))
+	span.Finish()
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Errorf("Flush() failed with error: %v", err)
+	}
+}
+
+func TestStdout_Export(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	now := time.Date(2018, 2, 8, 7, 0, 0, 0, time.UTC)
+	tracer := NewTracer(&Stdout{W: &buf})
+	tracer.Now = func() time.Time {
+		now = now.Add(250 * time.Millisecond)
+		return now
+	}
+	ctx, root := tracer.Start(context.Background(), "run")
+	_, child := tracer.Start(ctx, "findScreenshot", "repo", "https://github.com/acme/rocket")
+	child.Finish()
+	root.Finish()
+	err := tracer.Flush(context.Background())
+	if err != nil {
+		t.Fatalf("Flush() failed with error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Exported %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+	got := stdoutSpan{}
+	err = json.Unmarshal([]byte(lines[0]), &got)
+	if err != nil {
+		t.Fatalf("Line %q is not JSON: %v", lines[0], err)
+	}
+	if got.Name != "findScreenshot" || got.ParentID != root.SpanID.String() || got.TraceID != root.TraceID.String() ||
+		got.Duration != 250 || got.Attributes["repo"] != "https://github.com/acme/rocket" {
+		t.Errorf("Exported %+v", got)
+	}
+}
@@ -0,0 +1,48 @@
+package trace
+
+import (
+	"net/http"
+)
+
+// Transport is an http.RoundTripper recording a client span for each request,
+// as a child of the span in the context of the request, and propagating it to
+// the server with the traceparent header.
+type Transport struct {
+	Tracer *Tracer
+	// Next sends the requests, http.DefaultTransport if nil.
+	Next http.RoundTripper
+}
+
+// NewTransport returns a transport tracing the requests sent with next.
+func NewTransport(tracer *Tracer, next http.RoundTripper) *Transport {
+	return &Transport{Tracer: tracer, Next: next}
+}
+
+// RoundTrip sends the request with the traceparent header of a new client span.
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if t.Tracer == nil {
+		return next.RoundTrip(r)
+	}
+
+	// Credentials in the query or user info are not recorded
+	u := *r.URL
+	u.User = nil
+	u.RawQuery = ""
+	ctx, span := t.Tracer.Start(r.Context(), "HTTP "+r.Method, "http.method", r.Method, "http.url", u.String())
+	span.Kind = Client
+	defer span.Finish()
+
+	r = r.Clone(ctx)
+	r.Header.Set("traceparent", span.Traceparent())
+	resp, err := next.RoundTrip(r)
+	if err != nil {
+		span.SetError(err)
+		return nil, err
+	}
+	span.SetAttributes("http.status_code", resp.StatusCode)
+	return resp, nil
+}
@@ -0,0 +1,56 @@
+package trace
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer server.Close()
+
+	exporter := &memoryExporter{}
+	tracer := NewTracer(exporter)
+	client := &http.Client{Transport: NewTransport(tracer, server.Client().Transport)}
+
+	ctx, parent := tracer.Start(context.Background(), "download")
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/2018/02/08?token=secret", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() failed with error: %v", err)
+	}
+	resp.Body.Close()
+	parent.Finish()
+	tracer.Flush(context.Background())
+
+	if len(exporter.spans) != 2 {
+		t.Fatalf("Exported %d spans, want the request and its parent", len(exporter.spans))
+	}
+	span := exporter.spans[0]
+	if span.Name != "HTTP GET" || span.Kind != Client || span.ParentID != parent.SpanID || span.TraceID != parent.TraceID {
+		t.Errorf("Request span = %+v, want a client span child of %s", span, parent.SpanID)
+	}
+	if traceparent != span.Traceparent() {
+		t.Errorf("Server received traceparent %q, want %q", traceparent, span.Traceparent())
+	}
+	want := []Attribute{{"http.method", "GET"}, {"http.url", server.URL + "/2018/02/08"}, {"http.status_code", http.StatusTeapot}}
+	if len(span.Attributes) != len(want) {
+		t.Fatalf("Attributes = %v, want %v", span.Attributes, want)
+	}
+	for i := range want {
+		if span.Attributes[i] != want[i] {
+			t.Errorf("Attribute %d = %v, want %v", i, span.Attributes[i], want[i])
+		}
+	}
+	if req.Header.Get("traceparent") != "" {
+		t.Errorf("The original request was modified")
+	}
+}